@@ -61,7 +61,7 @@ var AddCmd = &cobra.Command{
 		}
 
 		categoryId = lo.Capitalize(categoryId)
-		componentId = lo.Capitalize(componentId)
+		componentId = components.ResolveComponentId(categoryId, componentId)
 
 		err = components.NewConfigService().Add(
 			components.AddArgs{
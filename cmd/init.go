@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -20,6 +21,9 @@ var InitCmd = &cobra.Command{
 		var databaseProvider string
 		var databaseUrl string
 		var provisionDatabase string
+		var databasePort int
+		var timestamps string
+		var softDelete string
 
 		err := survey.AskOne(&survey.Input{Message: "Provide alchemy component root: ", Default: "."}, &root)
 		if err != nil {
@@ -44,19 +48,41 @@ var InitCmd = &cobra.Command{
 			return
 		}
 
-		err = survey.AskOne(
-			&survey.Select{
-				Message: "Provision Database with Docker Compose: ",
-				Options: []string{"Yes", "No"},
-				Default: "Yes",
-			},
-			&provisionDatabase,
-		)
+		profile, err := components.GetDatabaseProfile(databaseProvider)
 		if err != nil {
 			color.Red("%s", err)
 			return
 		}
 
+		// sqlite is a local file, so there's nothing to provision
+		if profile.Image == "" {
+			provisionDatabase = "Yes"
+		} else {
+			err = survey.AskOne(
+				&survey.Select{
+					Message: "Provision Database with Docker Compose: ",
+					Options: []string{"Yes", "No"},
+					Default: "Yes",
+				},
+				&provisionDatabase,
+			)
+			if err != nil {
+				color.Red("%s", err)
+				return
+			}
+		}
+
+		if profile.Image != "" && strings.ToLower(provisionDatabase) == "yes" {
+			err = survey.AskOne(
+				&survey.Input{Message: "Database port: ", Default: strconv.Itoa(profile.Port)},
+				&databasePort,
+			)
+			if err != nil {
+				color.Red("%s", err)
+				return
+			}
+		}
+
 		if strings.ToLower(provisionDatabase) == "no" {
 			err = survey.AskOne(&survey.Input{Message: "Provide database url: "}, &databaseUrl)
 			if err != nil {
@@ -65,12 +91,41 @@ var InitCmd = &cobra.Command{
 			}
 		}
 
+		err = survey.AskOne(
+			&survey.Select{
+				Message: "Add createdAt and updatedAt to models: ",
+				Options: []string{"Yes", "No"},
+				Default: "Yes",
+			},
+			&timestamps,
+		)
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = survey.AskOne(
+			&survey.Select{
+				Message: "Soft delete models: ",
+				Options: []string{"Yes", "No"},
+				Default: "No",
+			},
+			&softDelete,
+		)
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
 		err = components.NewConfigService().Init(components.InitArgs{
 			Root:                  root,
 			Orm:                   orm,
 			ShouldProvideDatabase: strings.ToLower(provisionDatabase) == "yes",
 			DatabaseUrl:           databaseUrl,
 			DatabaseProvider:      databaseProvider,
+			DatabasePort:          databasePort,
+			Timestamps:            strings.ToLower(timestamps) == "yes",
+			SoftDelete:            strings.ToLower(softDelete) == "yes",
 		})
 		if err != nil {
 			color.Red("%s", err)
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/struckchure/go-alchemy/components"
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database migrations",
+}
+
+var MigrateCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create new migration",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewMigrationService().Create(
+			components.MigrationCreateArgs{
+				Name: args[0],
+				Root: root,
+			},
+		)
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
+}
+
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewMigrationService().Up(components.MigrationArgs{Root: root})
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
+}
+
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the latest migration",
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewMigrationService().Down(components.MigrationArgs{Root: root})
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
+}
+
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration status",
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewMigrationService().Status(components.MigrationArgs{Root: root})
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
+}
+
+func init() {
+	MigrateCmd.AddCommand(MigrateCreateCmd)
+	MigrateCmd.AddCommand(MigrateUpCmd)
+	MigrateCmd.AddCommand(MigrateDownCmd)
+	MigrateCmd.AddCommand(MigrateStatusCmd)
+}
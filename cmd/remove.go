@@ -1,10 +1,32 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/struckchure/go-alchemy/components"
+)
 
 var RemoveCmd = &cobra.Command{
 	Use:   "remove",
-	Short: "Add new component",
+	Short: "Remove component",
 	Args:  cobra.ExactArgs(1),
-	Run:   func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewConfigService().Remove(
+			components.RemoveArgs{
+				Component: args[0],
+				Root:      root,
+			},
+		)
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
 }
@@ -12,6 +12,8 @@ func init() {
 	RootCmd.AddCommand(InitCmd)
 	RootCmd.AddCommand(AddCmd)
 	RootCmd.AddCommand(RemoveCmd)
+	RootCmd.AddCommand(MigrateCmd)
+	RootCmd.AddCommand(SeedCmd)
 
 	RootCmd.PersistentFlags().StringP("root", "r", ".", "Project root")
 }
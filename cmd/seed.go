@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/struckchure/go-alchemy/components"
+)
+
+var SeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Seed database with fixture data",
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		count, err := cmd.Flags().GetInt("count")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		seed, err := cmd.Flags().GetInt64("seed")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		reset, err := cmd.Flags().GetBool("reset")
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+
+		err = components.NewSeedService().Seed(
+			components.SeedArgs{
+				Root:  root,
+				Count: count,
+				Seed:  seed,
+				Reset: reset,
+			},
+		)
+		if err != nil {
+			color.Red("%s", err)
+			return
+		}
+	},
+}
+
+func init() {
+	SeedCmd.Flags().IntP("count", "c", 10, "Number of records to create per model")
+	SeedCmd.Flags().Int64P("seed", "s", 1, "Random seed, the same seed always creates the same records")
+	SeedCmd.Flags().Bool("reset", false, "Delete existing records before seeding")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"math/rand"
+	// @alchemy block {{- if eq .Orm "Ent" }}
+	"os"
+	// @alchemy block {{- end }}
+
+	_ "github.com/joho/godotenv/autoload"
+	// @alchemy block {{- if and (eq .Orm "Ent") (eq .DatabaseProvider "postgresql") }}
+	_ "github.com/lib/pq"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if and (eq .Orm "Ent") (eq .DatabaseProvider "mysql") }}
+	_ "github.com/go-sql-driver/mysql"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if and (eq .Orm "Ent") (eq .DatabaseProvider "sqlite") }}
+	_ "github.com/mattn/go-sqlite3"
+	// @alchemy block {{- end }}
+
+	// @alchemy statement "{{ .ModuleName }}/dao"
+	dao "github.com/struckchure/go-alchemy/orms/gorm"
+	// @alchemy block {{- if eq .Orm "Ent" }}
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .Orm "Prisma" }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/seeds"
+	"github.com/struckchure/go-alchemy/seeds"
+	// @alchemy block {{- if .Users }}
+	// @alchemy statement "{{ .ModuleName }}/services"
+	"github.com/struckchure/go-alchemy/services"
+	// @alchemy block {{- end }}
+)
+
+func main() {
+	count := flag.Int("count", 10, "number of records to create per model")
+	seed := flag.Int64("seed", 1, "random seed, the same seed always creates the same records")
+	reset := flag.Bool("reset", false, "delete existing records before seeding")
+	flag.Parse()
+
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(*seed))
+
+	// @alchemy block {{ if eq .Orm "Prisma" }}
+	client := db.NewClient()
+	err := client.Prisma.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Prisma.Disconnect()
+	// @alchemy block {{ end }}
+	// @alchemy block {{ if eq .Orm "Ent" }}
+	// @alchemy replace client, err := ent.Open("{{ if eq .DatabaseProvider "postgresql" }}postgres{{ else if eq .DatabaseProvider "sqlite" }}sqlite3{{ else }}{{ .DatabaseProvider }}{{ end }}", os.Getenv("DATABASE_URL"))
+	client, err := ent.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+	// @alchemy block {{ end }}
+	// @alchemy block {{ if and (ne .Orm "Prisma") (ne .Orm "Ent") }}
+	client, err := dao.NewDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// @alchemy block {{ end }}
+	// @alchemy block {{ if or (eq .Orm "Bun") (eq .Orm "Stdlib") }}
+	defer client.Close()
+	// @alchemy block {{ end }}
+	// @alchemy block {{ if eq .Orm "Mongo" }}
+	defer client.Client().Disconnect(ctx)
+	// @alchemy block {{ end }}
+
+	// @alchemy block {{ if .Users }}
+	// @alchemy block {{ if eq .Orm "Mongo" }}
+	userDao, err := dao.NewUserDao(client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// @alchemy block {{ else }}
+	userDao := dao.NewUserDao(client)
+	// @alchemy block {{ end }}
+
+	if *reset {
+		err = seeds.ResetUsers(ctx, userDao)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Println("Deleted existing users")
+	}
+
+	err = seeds.SeedUsers(ctx, userDao, services.NewPasswordHasher(), rng, *count)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Created %d users, their password is `%s`", *count, seeds.UserPassword)
+	// @alchemy block {{ end }}
+}
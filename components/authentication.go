@@ -9,6 +9,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/samber/lo"
 	"github.com/struckchure/go-alchemy/internals"
+	"github.com/struckchure/go-alchemy/orms"
 )
 
 type IAuthentication interface {
@@ -16,14 +17,34 @@ type IAuthentication interface {
 
 	Login() error
 	Register() error
+	Refresh() error
+	Logout() error
+	PasswordReset() error
+	EmailVerification() error
+	OAuth() error
+	MFA() error
+	MagicLink() error
+	APIKeys() error
+	Sessions() error
 }
 
+var ErrProviderNotSupported = errors.New("the component is not supported with this database provider")
+
 type Authentication struct{}
 
 func (a *Authentication) Setup(component string) (func() error, error) {
 	methods := map[string]func() error{
-		"Login":    a.Login,
-		"Register": a.Register,
+		"Login":             a.Login,
+		"Register":          a.Register,
+		"Refresh":           a.Refresh,
+		"Logout":            a.Logout,
+		"PasswordReset":     a.PasswordReset,
+		"EmailVerification": a.EmailVerification,
+		"OAuth":             a.OAuth,
+		"MFA":               a.MFA,
+		"MagicLink":         a.MagicLink,
+		"APIKeys":           a.APIKeys,
+		"Sessions":          a.Sessions,
 	}
 
 	if !lo.HasKey(methods, component) {
@@ -43,22 +64,59 @@ func (a *Authentication) PreSetup() error {
 		return internals.ErrAlchemyConfigNotFound
 	}
 
-	cmd := exec.Command("go", "get", "github.com/steebchen/prisma-client-go")
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return errors.Join(err, errors.New(string(out)))
+	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range orms.OrmDependencies[cfg.Orm.Name] {
+		cmd := exec.Command("go", "get", dependency)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.Join(err, errors.New(string(out)))
+		}
 	}
 
 	return nil
 }
 
-func (a *Authentication) PostSetup() error {
+func (a *Authentication) PostSetup(component string) error {
 	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
 	if err != nil {
 		return err
 	}
 
-	if cfg.Orm.Name == "Prisma" {
-		cmd := exec.Command("go", "run", "github.com/steebchen/prisma-client-go", "db", "push")
+	switch {
+	case cfg.Orm.Name == "Prisma":
+		cmd := exec.Command("go", "run", "github.com/steebchen/prisma-client-go", "format")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.Join(err, errors.New(string(out)))
+		}
+
+		// mongodb has no migrations with prisma
+		if strings.ToLower(cfg.Orm.DatabaseProvider) == "mongodb" {
+			cmd = exec.Command("go", "run", "github.com/steebchen/prisma-client-go", "db", "push")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return errors.Join(err, errors.New(string(out)))
+			}
+
+			break
+		}
+
+		// `migrate dev` would prompt before applying, so the migration is only
+		// created and `go-alchemy migrate up` applies it
+		err := NewMigrationService().Create(MigrationCreateArgs{Root: ".", Name: component})
+		if err != nil {
+			return err
+		}
+
+		color.Yellow("Run `go-alchemy migrate up` to apply the migration")
+	case lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name):
+		err := NewMigrationService().Up(MigrationArgs{Root: "."})
+		if err != nil {
+			return err
+		}
+	case cfg.Orm.Name == "Ent":
+		cmd := exec.Command("go", "generate", "./ent")
 		if out, err := cmd.CombinedOutput(); err != nil {
 			return errors.Join(err, errors.New(string(out)))
 		}
@@ -72,8 +130,23 @@ func (a *Authentication) PostSetup() error {
 	return nil
 }
 
-func (a *Authentication) Login() (err error) {
-	componentId := "Authentication.Login"
+// guardUnsupportedProvider fails the components which can't work with the
+// database provider of cfg. Single use tokens are used with conditional
+// updates and revoked with deletes, clickhouse doesn't report the rows of the
+// former, deletes asynchronously and doesn't enforce unique indexes either.
+func guardUnsupportedProvider(cfg *Config) error {
+	if cfg.Orm.DatabaseProvider == "Clickhouse" {
+		return fmt.Errorf("%w [%s]", ErrProviderNotSupported, cfg.Orm.DatabaseProvider)
+	}
+
+	return nil
+}
+
+// generate creates a component of the Authentication module, as described by
+// authenticationComponents
+func (a *Authentication) generate(component string) (err error) {
+	componentId := "Authentication." + component
+
 	defer func() {
 		if err == nil {
 			color.Green("+ %s", componentId)
@@ -84,69 +157,94 @@ func (a *Authentication) Login() (err error) {
 
 	color.Green("Creating %s component", componentId)
 
-	moduleName, err := GetModuleName()
-	if err != nil {
-		return err
-	}
-
-	loginTmpls, err := GetLoginTemplates()
+	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
 	if err != nil {
 		return err
 	}
 
-	err = GenerateMultipleTmpls(GenerateMultipleTmplsArgs{
-		ComponentId: strings.Split(componentId, ".")[0],
-		Tmpls:       loginTmpls,
-		Values: map[string]interface{}{
-			"Login":      true,
-			"User":       true,
-			"ModuleName": moduleName,
-		},
-	})
+	definition, err := getAuthenticationComponent(cfg, component)
 	if err != nil {
 		return err
 	}
 
-	return a.PostSetup()
-}
-
-func (a *Authentication) Register() (err error) {
-	componentId := "Authentication.Register"
-
-	defer func() {
-		if err == nil {
-			color.Green("+ %s", componentId)
-		} else {
-			color.Red("x %s", componentId)
+	if definition.GuardProvider {
+		err = guardUnsupportedProvider(cfg)
+		if err != nil {
+			return err
 		}
-	}()
-
-	color.Green("Creating %s component", componentId)
+	}
 
 	moduleName, err := GetModuleName()
 	if err != nil {
 		return err
 	}
 
-	registerTmpls, err := GetRegisterTemplates()
+	tmpls, err := GetAuthenticationTemplates(component)
 	if err != nil {
 		return err
 	}
 
+	values := map[string]interface{}{"ModuleName": moduleName}
+	for _, flag := range definition.Flags {
+		values[flag] = true
+	}
+
 	err = GenerateMultipleTmpls(GenerateMultipleTmplsArgs{
 		ComponentId: strings.Split(componentId, ".")[0],
-		Tmpls:       registerTmpls,
-		Values: map[string]interface{}{
-			"Register":   true,
-			"User":       true,
-			"ModuleName": moduleName,
-		},
+		Tmpls:       tmpls,
+		Values:      values,
+		Migration:   &ModelMigration{Name: componentId, Models: definition.Models},
+		Compose:     definition.Compose,
 	})
 	if err != nil {
 		return err
 	}
 
-	return a.PostSetup()
+	return a.PostSetup(componentId)
+}
+
+func (a *Authentication) Login() error {
+	return a.generate("Login")
+}
+
+func (a *Authentication) Register() error {
+	return a.generate("Register")
+}
+
+func (a *Authentication) Refresh() error {
+	return a.generate("Refresh")
+}
+
+func (a *Authentication) Logout() error {
+	return a.generate("Logout")
+}
+
+func (a *Authentication) PasswordReset() error {
+	return a.generate("PasswordReset")
+}
+
+func (a *Authentication) EmailVerification() error {
+	return a.generate("EmailVerification")
+}
+
+func (a *Authentication) OAuth() error {
+	return a.generate("OAuth")
+}
+
+func (a *Authentication) MFA() error {
+	return a.generate("MFA")
+}
+
+func (a *Authentication) MagicLink() error {
+	return a.generate("MagicLink")
+}
+
+func (a *Authentication) APIKeys() error {
+	return a.generate("APIKeys")
+}
+
+func (a *Authentication) Sessions() error {
+	return a.generate("Sessions")
 }
 
 func NewAuthentication() IAuthentication {
@@ -1,6 +1,11 @@
 package components
 
-import "github.com/struckchure/go-alchemy/internals"
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/struckchure/go-alchemy/internals"
+)
 
 var prismaTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 	{
@@ -14,6 +19,18 @@ var prismaTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 		OutputPath: "dao/user.go",
 		GoFormat:   true,
 	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/prisma/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/prisma/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
 }
 
 var gormTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
@@ -23,6 +40,18 @@ var gormTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 		OutputPath: "dao/user.go",
 		GoFormat:   true,
 	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/gorm/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/gorm/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
 	{
 		Id:         "Models.Utils",
 		TmplPath:   "orms/gorm/utils.go",
@@ -31,6 +60,121 @@ var gormTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 	},
 }
 
+var entTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Models.User",
+		TmplPath:   "ent/schema/user.go",
+		OutputPath: "ent/schema/user.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.UserDao",
+		TmplPath:   "orms/ent/user.go",
+		OutputPath: "dao/user.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/ent/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/ent/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
+}
+
+var bunTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Models.UserDao",
+		TmplPath:   "orms/bun/user.go",
+		OutputPath: "dao/user.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/bun/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/bun/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
+}
+
+var stdlibTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Models.UserDao",
+		TmplPath:   "orms/stdlib/user.go",
+		OutputPath: "dao/user.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/stdlib/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/stdlib/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
+}
+
+var mongoTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Models.UserDao",
+		TmplPath:   "orms/mongo/user.go",
+		OutputPath: "dao/user.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.Tx",
+		TmplPath:   "orms/mongo/tx.go",
+		OutputPath: "dao/tx.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.DriverErrors",
+		TmplPath:   "orms/mongo/driver_errors.go",
+		OutputPath: "dao/driver_errors.go",
+		GoFormat:   true,
+	},
+}
+
+// modelTmpls are shared by the models of every orm
+var modelTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Models.Pagination",
+		TmplPath:   "orms/shared/pagination.go",
+		OutputPath: "dao/pagination.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Models.Errors",
+		TmplPath:   "orms/shared/errors.go",
+		OutputPath: "dao/errors.go",
+		GoFormat:   true,
+	},
+}
+
+var ormTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": prismaTmpls,
+	"Gorm":   gormTmpls,
+	"Ent":    entTmpls,
+	"Bun":    bunTmpls,
+	"Stdlib": stdlibTmpls,
+	"Mongo":  mongoTmpls,
+}
+
 var sharedTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 	{
 		Id:         "Services.Utils",
@@ -44,6 +188,12 @@ var sharedTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
 		OutputPath: "services/jwt.go",
 		GoFormat:   true,
 	},
+	{
+		Id:         "Services.Password",
+		TmplPath:   "services/password.go",
+		OutputPath: "services/password.go",
+		GoFormat:   true,
+	},
 }
 
 var loginTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
@@ -62,36 +212,846 @@ var registerTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
 		OutputPath: "services/authentication.go",
 		GoFormat:   true,
 	},
+	{
+		Id:         "Seeds.Users",
+		TmplPath:   "seeds/users.go",
+		OutputPath: "seeds/users.go",
+		GoFormat:   true,
+	},
+}, sharedTmpls...)
+
+var refreshTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.Refresh",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+}, sharedTmpls...)
+
+// refreshTokenTmpls are the refresh token models of each orm
+var refreshTokenTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.RefreshToken",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/prisma/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/gorm/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.RefreshToken",
+			TmplPath:   "ent/schema/refresh_token.go",
+			OutputPath: "ent/schema/refresh_token.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/ent/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/bun/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/stdlib/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.RefreshTokenDao",
+			TmplPath:   "orms/mongo/refresh_token.go",
+			OutputPath: "dao/refresh_token.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+// logoutServiceTmpls are the services of logout without the shared ones, the
+// components revoking tokens set them up with their own
+var logoutServiceTmpls []GenerateSingleTmplArgs = []GenerateSingleTmplArgs{
+	{
+		Id:         "Services.Logout",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.TokenRevocation",
+		TmplPath:   "services/token_revocation.go",
+		OutputPath: "services/token_revocation.go",
+		GoFormat:   true,
+	},
+}
+
+var logoutTmpls []GenerateSingleTmplArgs = append(logoutServiceTmpls, sharedTmpls...)
+
+// revokedTokenTmpls are the revoked token models of each orm
+var revokedTokenTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.RevokedToken",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/prisma/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/gorm/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.RevokedToken",
+			TmplPath:   "ent/schema/revoked_token.go",
+			OutputPath: "ent/schema/revoked_token.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/ent/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/bun/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/stdlib/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.RevokedTokenDao",
+			TmplPath:   "orms/mongo/revoked_token.go",
+			OutputPath: "dao/revoked_token.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+var mailerTmpl GenerateSingleTmplArgs = GenerateSingleTmplArgs{
+	Id:         "Services.Mailer",
+	TmplPath:   "services/mailer.go",
+	OutputPath: "services/mailer.go",
+	GoFormat:   true,
+}
+
+// passwordResetTmpls include logoutTmpls, resetting a password revokes the
+// tokens issued with the old one
+var passwordResetTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.PasswordReset",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	mailerTmpl,
+}, logoutTmpls...)
+
+// passwordResetTokenTmpls are the password reset token models of each orm
+var passwordResetTokenTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.PasswordResetToken",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/prisma/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/gorm/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.PasswordResetToken",
+			TmplPath:   "ent/schema/password_reset_token.go",
+			OutputPath: "ent/schema/password_reset_token.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/ent/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/bun/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/stdlib/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.PasswordResetTokenDao",
+			TmplPath:   "orms/mongo/password_reset_token.go",
+			OutputPath: "dao/password_reset_token.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+var emailVerificationTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.EmailVerification",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	mailerTmpl,
+}, sharedTmpls...)
+
+// oauthTmpls include the tests of the providers, which run against a mock
+// OpenID Connect server
+var oauthTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.OAuth",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.OAuthProviders",
+		TmplPath:   "services/oauth.go",
+		OutputPath: "services/oauth.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.OAuthTest",
+		TmplPath:   "services/oauth_test.go",
+		OutputPath: "services/oauth_test.go",
+		GoFormat:   true,
+	},
+}, sharedTmpls...)
+
+// linkedAccountTmpls are the linked account models of each orm
+var linkedAccountTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.LinkedAccount",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/prisma/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/gorm/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.LinkedAccount",
+			TmplPath:   "ent/schema/linked_account.go",
+			OutputPath: "ent/schema/linked_account.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/ent/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/bun/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/stdlib/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.LinkedAccountDao",
+			TmplPath:   "orms/mongo/linked_account.go",
+			OutputPath: "dao/linked_account.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+// mfaTmpls include loginTmpls, a login waits for the code of users who enabled MFA
+var mfaTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.MFA",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.Totp",
+		TmplPath:   "services/totp.go",
+		OutputPath: "services/totp.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.RecoveryCode",
+		TmplPath:   "services/recovery_code.go",
+		OutputPath: "services/recovery_code.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.RateLimiter",
+		TmplPath:   "services/rate_limiter.go",
+		OutputPath: "services/rate_limiter.go",
+		GoFormat:   true,
+	},
+}, append(logoutServiceTmpls, loginTmpls...)...)
+
+// recoveryCodeTmpls are the recovery code models of each orm
+var recoveryCodeTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.RecoveryCode",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/prisma/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/gorm/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.RecoveryCode",
+			TmplPath:   "ent/schema/recovery_code.go",
+			OutputPath: "ent/schema/recovery_code.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/ent/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/bun/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/stdlib/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.RecoveryCodeDao",
+			TmplPath:   "orms/mongo/recovery_code.go",
+			OutputPath: "dao/recovery_code.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+// magicLinkTmpls include the rate limiter of the links requested per email
+var magicLinkTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.MagicLink",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.RateLimiter",
+		TmplPath:   "services/rate_limiter.go",
+		OutputPath: "services/rate_limiter.go",
+		GoFormat:   true,
+	},
+	mailerTmpl,
 }, sharedTmpls...)
 
-func GetLoginTemplates() ([]GenerateSingleTmplArgs, error) {
+// magicLinkTokenTmpls are the magic link token models of each orm
+var magicLinkTokenTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.MagicLinkToken",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/prisma/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/gorm/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.MagicLinkToken",
+			TmplPath:   "ent/schema/magic_link_token.go",
+			OutputPath: "ent/schema/magic_link_token.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/ent/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/bun/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/stdlib/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.MagicLinkTokenDao",
+			TmplPath:   "orms/mongo/magic_link_token.go",
+			OutputPath: "dao/magic_link_token.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+var apiKeysTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.APIKeys",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+}, sharedTmpls...)
+
+// apiKeyTmpls are the api key models of each orm
+var apiKeyTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.ApiKey",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/prisma/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/gorm/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.ApiKey",
+			TmplPath:   "ent/schema/api_key.go",
+			OutputPath: "ent/schema/api_key.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/ent/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/bun/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/stdlib/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.ApiKeyDao",
+			TmplPath:   "orms/mongo/api_key.go",
+			OutputPath: "dao/api_key.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+// sessionsTmpls include loginTmpls, a login can start a session instead of
+// returning tokens
+var sessionsTmpls []GenerateSingleTmplArgs = append([]GenerateSingleTmplArgs{
+	{
+		Id:         "Services.Sessions",
+		TmplPath:   "services/authentication.go",
+		OutputPath: "services/authentication.go",
+		GoFormat:   true,
+	},
+	{
+		Id:         "Services.Session",
+		TmplPath:   "services/session.go",
+		OutputPath: "services/session.go",
+		GoFormat:   true,
+	},
+}, loginTmpls...)
+
+// redisSessionStoreTmpl is added to Authentication.Sessions with the redis
+// session store, see SessionOptions
+var redisSessionStoreTmpl GenerateSingleTmplArgs = GenerateSingleTmplArgs{
+	Id:         "Services.RedisSessionStore",
+	TmplPath:   "services/redis_session_store.go",
+	OutputPath: "services/redis_session_store.go",
+	GoFormat:   true,
+}
+
+// sessionTmpls are the session models of each orm
+var sessionTmpls map[string][]GenerateSingleTmplArgs = map[string][]GenerateSingleTmplArgs{
+	"Prisma": {
+		{
+			Id:         "Models.Session",
+			TmplPath:   "prisma/schema.prisma",
+			OutputPath: "prisma/schema.prisma",
+		},
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/prisma/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+	"Gorm": {
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/gorm/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+	"Ent": {
+		{
+			Id:         "Models.Session",
+			TmplPath:   "ent/schema/session.go",
+			OutputPath: "ent/schema/session.go",
+			GoFormat:   true,
+		},
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/ent/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+	"Bun": {
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/bun/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+	"Stdlib": {
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/stdlib/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+	"Mongo": {
+		{
+			Id:         "Models.SessionDao",
+			TmplPath:   "orms/mongo/session.go",
+			OutputPath: "dao/session.go",
+			GoFormat:   true,
+		},
+	},
+}
+
+// mailpitDependency catches the mails sent by SmtpMailer during development,
+// they can be read at http://localhost:8025
+var mailpitDependency ComposeDependency = ComposeDependency{
+	Name: "mailpit",
+	Service: internals.ComposeService{
+		Image: "axllent/mailpit:latest",
+		Ports: []string{"1025:1025", "8025:8025"},
+	},
+	Env: map[string]string{
+		"SMTP_HOST": "localhost",
+		"SMTP_PORT": "1025",
+	},
+}
+
+// redisDependency keeps the sessions of RedisSessionStore during development
+var redisDependency ComposeDependency = ComposeDependency{
+	Name: "redis",
+	Service: internals.ComposeService{
+		Image: "redis:7-alpine",
+		Ports: []string{"6379:6379"},
+	},
+	Env: map[string]string{
+		"REDIS_URL": "redis://localhost:6379/0",
+	},
+}
+
+// withOrmTmpls returns a new slice of tmpls plus the model templates of the configured orm
+func withOrmTmpls(tmpls []GenerateSingleTmplArgs) ([]GenerateSingleTmplArgs, error) {
 	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
 	if err != nil {
 		return nil, err
 	}
 
-	switch cfg.Orm.Name {
-	case "Prisma":
-		loginTmpls = append(loginTmpls, prismaTmpls...)
-	case "Gorm":
-		loginTmpls = append(loginTmpls, gormTmpls...)
+	if !lo.HasKey(ormTmpls, cfg.Orm.Name) {
+		return nil, fmt.Errorf("orm `%s` is not supported", cfg.Orm.Name)
 	}
 
-	return loginTmpls, nil
+	return append(append(append([]GenerateSingleTmplArgs{}, tmpls...), modelTmpls...), ormTmpls[cfg.Orm.Name]...), nil
 }
 
-func GetRegisterTemplates() ([]GenerateSingleTmplArgs, error) {
+// authenticationComponent is what a component of the Authentication module
+// generates
+type authenticationComponent struct {
+	Tmpls []GenerateSingleTmplArgs
+	// ModelTmpls are the templates of the models of the component, by orm
+	ModelTmpls []map[string][]GenerateSingleTmplArgs
+	// Flags are the template values the component enables
+	Flags []string
+	// Models are the models of the component's migration
+	Models  []string
+	Compose []ComposeDependency
+	// GuardProvider fails the component with the database providers
+	// guardUnsupportedProvider rejects
+	GuardProvider bool
+}
+
+var authenticationComponents map[string]authenticationComponent = map[string]authenticationComponent{
+	"Login": {
+		Tmpls:  loginTmpls,
+		Flags:  []string{"Login", "User"},
+		Models: []string{"User"},
+	},
+	"Register": {
+		Tmpls:  registerTmpls,
+		Flags:  []string{"Register", "User"},
+		Models: []string{"User"},
+	},
+	"Refresh": {
+		Tmpls:         refreshTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{refreshTokenTmpls},
+		Flags:         []string{"Refresh", "User", "RefreshToken"},
+		Models:        []string{"User", "RefreshToken"},
+		GuardProvider: true,
+	},
+	"Logout": {
+		Tmpls:      logoutTmpls,
+		ModelTmpls: []map[string][]GenerateSingleTmplArgs{revokedTokenTmpls},
+		Flags:      []string{"Logout", "User", "RevokedToken"},
+		Models:     []string{"User", "RevokedToken"},
+	},
+	"PasswordReset": {
+		Tmpls:         passwordResetTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{passwordResetTokenTmpls, revokedTokenTmpls},
+		Flags:         []string{"PasswordReset", "Logout", "User", "PasswordResetToken", "RevokedToken"},
+		Models:        []string{"User", "RevokedToken", "PasswordResetToken"},
+		Compose:       []ComposeDependency{mailpitDependency},
+		GuardProvider: true,
+	},
+	"EmailVerification": {
+		Tmpls:   emailVerificationTmpls,
+		Flags:   []string{"EmailVerification", "User"},
+		Models:  []string{"User", "UserEmailVerification"},
+		Compose: []ComposeDependency{mailpitDependency},
+	},
+	"OAuth": {
+		Tmpls:         oauthTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{linkedAccountTmpls},
+		Flags:         []string{"OAuth", "User", "LinkedAccount"},
+		Models:        []string{"User", "LinkedAccount"},
+		GuardProvider: true,
+	},
+	"MFA": {
+		Tmpls:         mfaTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{recoveryCodeTmpls, revokedTokenTmpls},
+		Flags:         []string{"MFA", "Login", "Logout", "User", "RecoveryCode", "RevokedToken"},
+		Models:        []string{"User", "UserMfa", "RevokedToken", "RecoveryCode"},
+		GuardProvider: true,
+	},
+	"MagicLink": {
+		Tmpls:         magicLinkTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{magicLinkTokenTmpls},
+		Flags:         []string{"MagicLink", "User", "MagicLinkToken"},
+		Models:        []string{"User", "MagicLinkToken"},
+		Compose:       []ComposeDependency{mailpitDependency},
+		GuardProvider: true,
+	},
+	"APIKeys": {
+		Tmpls:         apiKeysTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{apiKeyTmpls},
+		Flags:         []string{"APIKeys", "User", "ApiKey"},
+		Models:        []string{"User", "ApiKey"},
+		GuardProvider: true,
+	},
+	"Sessions": {
+		Tmpls:         sessionsTmpls,
+		ModelTmpls:    []map[string][]GenerateSingleTmplArgs{sessionTmpls},
+		Flags:         []string{"Sessions", "Login", "User", "Session"},
+		Models:        []string{"User", "Session"},
+		GuardProvider: true,
+	},
+}
+
+// getAuthenticationComponent returns a component of the Authentication
+// module with the options of cfg
+func getAuthenticationComponent(cfg *Config, component string) (*authenticationComponent, error) {
+	definition := authenticationComponents[component]
+
+	if component == "Sessions" {
+		switch cfg.Sessions.Store {
+		case "", "database":
+		case "redis":
+			definition.Tmpls = append(append([]GenerateSingleTmplArgs{}, definition.Tmpls...), redisSessionStoreTmpl)
+			definition.Compose = []ComposeDependency{redisDependency}
+		default:
+			return nil, fmt.Errorf("session store `%s` is not supported", cfg.Sessions.Store)
+		}
+	}
+
+	return &definition, nil
+}
+
+// GetAuthenticationTemplates returns the templates of a component of the
+// Authentication module, with the model templates of the configured orm
+func GetAuthenticationTemplates(component string) ([]GenerateSingleTmplArgs, error) {
 	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
 	if err != nil {
 		return nil, err
 	}
 
-	switch cfg.Orm.Name {
-	case "Prisma":
-		registerTmpls = append(registerTmpls, prismaTmpls...)
-	case "Gorm":
-		registerTmpls = append(registerTmpls, gormTmpls...)
+	definition, err := getAuthenticationComponent(cfg, component)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpls := append([]GenerateSingleTmplArgs{}, definition.Tmpls...)
+	for _, modelTmpls := range definition.ModelTmpls {
+		tmpls = append(tmpls, modelTmpls[cfg.Orm.Name]...)
 	}
 
-	return loginTmpls, nil
+	return withOrmTmpls(tmpls)
 }
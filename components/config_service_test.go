@@ -0,0 +1,91 @@
+package components
+
+import (
+	"os"
+	"testing"
+
+	"github.com/struckchure/go-alchemy/internals"
+)
+
+// inTempDir runs the test within an empty directory, as the components work
+// with the files of the current directory
+func inTempDir(t *testing.T) string {
+	directory := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	return directory
+}
+
+func TestRemoveKeepsExistingComposeServices(t *testing.T) {
+	directory := inTempDir(t)
+
+	err := internals.WriteYaml("alchemy.yaml", Config{Root: ".", Orm: Orm{Name: "Gorm", DatabaseProvider: "PostgreSQL"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the user's own redis, with a volume of the same name the component uses
+	_, _, err = internals.MergeCompose("docker-compose.yaml", internals.ComposeFile{
+		Name:     "app",
+		Services: map[string]internals.ComposeService{"redis": {Image: "redis:6", Volumes: []string{"redis-data:/data"}}},
+		Volumes:  map[string]internals.ComposeVolume{"redis-data": {}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redis := redisDependency
+	redis.Volumes = []string{"redis-data"}
+
+	err = GenerateMultipleTmpls(GenerateMultipleTmplsArgs{
+		ComponentId: "Authentication",
+		Values:      map[string]interface{}{},
+		Compose:     []ComposeDependency{redis, mailpitDependency},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	component := cfg.Components[0]
+	if len(component.ComposeServices) != 1 || component.ComposeServices[0] != "mailpit" || len(component.ComposeVolumes) != 0 {
+		t.Fatalf("only mailpit should be recorded, got %v %v", component.ComposeServices, component.ComposeVolumes)
+	}
+
+	err = NewConfigService().Remove(RemoveArgs{Component: "Authentication", Root: directory})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compose, err := internals.ReadYaml[internals.ComposeFile]("docker-compose.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compose.Services["redis"].Image != "redis:6" {
+		t.Fatalf("the existing redis service was removed: %v", compose.Services)
+	}
+
+	if _, ok := compose.Volumes["redis-data"]; !ok {
+		t.Fatalf("the existing redis volume was removed: %v", compose.Volumes)
+	}
+
+	if _, ok := compose.Services["mailpit"]; ok {
+		t.Fatal("mailpit was added by the component and should be removed")
+	}
+}
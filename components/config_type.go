@@ -1,24 +1,58 @@
 package components
 
+import "github.com/struckchure/go-alchemy/internals"
+
 type Dependency struct {
 	Id   string `yaml:"Id"`
 	Path string `yaml:"Path"`
 }
 
 type Component struct {
-	Id       string       `yaml:"Id"`
-	Models   []Dependency `yaml:"Models"`
-	Services []Dependency `yaml:"Services"`
+	Id              string       `yaml:"Id"`
+	Models          []Dependency `yaml:"Models"`
+	Services        []Dependency `yaml:"Services"`
+	Seeds           []Dependency `yaml:"Seeds,omitempty"`
+	ComposeServices []string     `yaml:"ComposeServices,omitempty"`
+	ComposeVolumes  []string     `yaml:"ComposeVolumes,omitempty"`
+	Migrations      []Dependency `yaml:"Migrations,omitempty"`
+}
+
+// ComposeDependency is a Docker Compose service a component needs to run
+// locally, e.g redis for sessions or mailpit for emails.
+type ComposeDependency struct {
+	Name    string
+	Service internals.ComposeService
+	Volumes []string
+	// Env are the .env entries for connecting to the service
+	Env map[string]string
 }
 
 type Orm struct {
 	Name             string `yaml:"Name"`
 	DatabaseProvider string `yaml:"DatabaseProvider"`
+	DatabasePort     int    `yaml:"DatabasePort,omitempty"`
+}
+
+// ModelOptions apply to every generated model
+type ModelOptions struct {
+	// Timestamps adds createdAt and updatedAt
+	Timestamps bool `yaml:"Timestamps"`
+	// SoftDelete adds deletedAt, deleted records are hidden instead of removed
+	SoftDelete bool `yaml:"SoftDelete"`
+}
+
+// SessionOptions apply to the Authentication.Sessions component
+type SessionOptions struct {
+	// Store keeps the sessions, `database` (the default) or `redis`, which adds
+	// the redis store and a redis service to docker-compose.yaml
+	Store string `yaml:"Store,omitempty"`
 }
 
 type Config struct {
-	ProjectName string      `yaml:"ProjectName"`
-	Root        string      `yaml:"Root"`
-	Orm         Orm         `yaml:"Orm"`
-	Components  []Component `yaml:"Components"`
+	ProjectName string         `yaml:"ProjectName"`
+	Root        string         `yaml:"Root"`
+	Orm         Orm            `yaml:"Orm"`
+	Models      ModelOptions   `yaml:"Models"`
+	Sessions    SessionOptions `yaml:"Sessions,omitempty"`
+	Components  []Component    `yaml:"Components"`
 }
@@ -1,5 +1,11 @@
 package components
 
+import (
+	"strings"
+
+	"github.com/samber/lo"
+)
+
 var ComponentCategoryOptions []string = []string{
 	"Authentication",
 	"Authorization",
@@ -19,6 +25,15 @@ var ComponentMapping map[string][]string = map[string][]string{
 var AuthenticationOptions []string = []string{
 	"Login",
 	"Register",
+	"Refresh",
+	"Logout",
+	"PasswordReset",
+	"EmailVerification",
+	"OAuth",
+	"MFA",
+	"MagicLink",
+	"APIKeys",
+	"Sessions",
 }
 
 var AuthorizationOptions []string = []string{
@@ -31,3 +46,14 @@ var ProductsOptions []string = []string{}
 var OrdersOptions []string = []string{}
 
 var MediaOptions []string = []string{}
+
+// ResolveComponentId matches id case insensitively against the components of
+// a category, e.g `passwordreset` becomes `PasswordReset`
+func ResolveComponentId(categoryId string, id string) string {
+	componentId, ok := lo.Find(ComponentMapping[categoryId], func(c string) bool { return strings.EqualFold(c, id) })
+	if !ok {
+		return lo.Capitalize(id)
+	}
+
+	return componentId
+}
@@ -0,0 +1,242 @@
+package components
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/struckchure/go-alchemy/internals"
+)
+
+// DatabaseCredentials are the credentials a provisioned database is created with
+type DatabaseCredentials struct {
+	User     string
+	Password string
+	Name     string
+	Port     int
+}
+
+// DatabaseProfile describes how a database provider is provisioned with
+// Docker Compose and how each ORM connects to it. It is the single source of
+// truth for both the compose service and DATABASE_URL.
+type DatabaseProfile struct {
+	Service string
+	Image   string
+	Command string
+	// Port inside the container, also used as the default host port
+	Port     int
+	DataPath string
+	User     string
+	// Database is a fixed database name for images that can't create one
+	Database    string
+	Environment func(DatabaseCredentials) []string
+	Url         func(orm string, credentials DatabaseCredentials) string
+}
+
+var DatabaseProfiles map[string]DatabaseProfile = map[string]DatabaseProfile{
+	"postgresql": {
+		Service:  "postgres",
+		Image:    "postgres:17-alpine",
+		Port:     5432,
+		DataPath: "/var/lib/postgresql/data",
+		User:     "alchemy",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{
+				"POSTGRES_USER=" + c.User,
+				"POSTGRES_PASSWORD=" + c.Password,
+				"POSTGRES_DB=" + c.Name,
+			}
+		},
+		Url: func(orm string, c DatabaseCredentials) string {
+			query := "sslmode=disable"
+			if orm == "Prisma" {
+				query = "schema=public"
+			}
+
+			return fmt.Sprintf("postgresql://%s:%s@localhost:%d/%s?%s", c.User, c.Password, c.Port, c.Name, query)
+		},
+	},
+	"mysql": {
+		Service:  "mysql",
+		Image:    "mysql:8.4",
+		Port:     3306,
+		DataPath: "/var/lib/mysql",
+		User:     "alchemy",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{
+				"MYSQL_ROOT_PASSWORD=" + c.Password,
+				"MYSQL_DATABASE=" + c.Name,
+				"MYSQL_USER=" + c.User,
+				"MYSQL_PASSWORD=" + c.Password,
+			}
+		},
+		Url: func(orm string, c DatabaseCredentials) string {
+			if orm == "Prisma" {
+				return fmt.Sprintf("mysql://%s:%s@localhost:%d/%s", c.User, c.Password, c.Port, c.Name)
+			}
+
+			// clientFoundRows counts the rows an update matched, not only the
+			// changed ones, so updating a row to its own values isn't taken
+			// for a missing row
+			return fmt.Sprintf("%s:%s@tcp(localhost:%d)/%s?parseTime=true&clientFoundRows=true", c.User, c.Password, c.Port, c.Name)
+		},
+	},
+	"sqlserver": {
+		Service:  "sqlserver",
+		Image:    "mcr.microsoft.com/mssql/server:2022-latest",
+		Port:     1433,
+		DataPath: "/var/opt/mssql",
+		User:     "sa",
+		Database: "master",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{
+				"ACCEPT_EULA=Y",
+				"MSSQL_SA_PASSWORD=" + c.Password,
+			}
+		},
+		Url: func(orm string, c DatabaseCredentials) string {
+			if orm == "Prisma" {
+				return fmt.Sprintf(
+					"sqlserver://localhost:%d;database=%s;user=%s;password=%s;trustServerCertificate=true",
+					c.Port, c.Name, c.User, c.Password,
+				)
+			}
+
+			return fmt.Sprintf("sqlserver://%s:%s@localhost:%d?database=%s", c.User, c.Password, c.Port, c.Name)
+		},
+	},
+	"mongodb": {
+		Service:  "mongodb",
+		Image:    "mongo:7",
+		Port:     27017,
+		DataPath: "/data/db",
+		User:     "alchemy",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{
+				"MONGO_INITDB_ROOT_USERNAME=" + c.User,
+				"MONGO_INITDB_ROOT_PASSWORD=" + c.Password,
+				"MONGO_INITDB_DATABASE=" + c.Name,
+			}
+		},
+		Url: func(_ string, c DatabaseCredentials) string {
+			return fmt.Sprintf("mongodb://%s:%s@localhost:%d/%s?authSource=admin", c.User, c.Password, c.Port, c.Name)
+		},
+	},
+	"cockroachdb": {
+		Service:  "cockroachdb",
+		Image:    "cockroachdb/cockroach:latest",
+		Command:  "start-single-node --insecure",
+		Port:     26257,
+		DataPath: "/cockroach/cockroach-data",
+		// insecure single nodes only accept the root user without a password
+		User: "root",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{"COCKROACH_DATABASE=" + c.Name}
+		},
+		Url: func(_ string, c DatabaseCredentials) string {
+			return fmt.Sprintf("postgresql://%s@localhost:%d/%s?sslmode=disable", c.User, c.Port, c.Name)
+		},
+	},
+	"clickhouse": {
+		Service:  "clickhouse",
+		Image:    "clickhouse/clickhouse-server:latest",
+		Port:     9000,
+		DataPath: "/var/lib/clickhouse",
+		User:     "alchemy",
+		Environment: func(c DatabaseCredentials) []string {
+			return []string{
+				"CLICKHOUSE_USER=" + c.User,
+				"CLICKHOUSE_PASSWORD=" + c.Password,
+				"CLICKHOUSE_DB=" + c.Name,
+			}
+		},
+		Url: func(_ string, c DatabaseCredentials) string {
+			return fmt.Sprintf("clickhouse://%s:%s@localhost:%d/%s", c.User, c.Password, c.Port, c.Name)
+		},
+	},
+	// sqlite is a local file, so it has no compose service
+	"sqlite": {
+		Url: func(orm string, c DatabaseCredentials) string {
+			if orm == "Prisma" {
+				// relative to prisma/schema.prisma
+				return fmt.Sprintf("file:./%s.db", c.Name)
+			}
+
+			// modernc stores times with time.Time.String() otherwise, which
+			// can't be compared with the times the DAOs bind
+			return fmt.Sprintf("file:%s.db?_time_format=sqlite", c.Name)
+		},
+	},
+}
+
+// GetDatabaseProfile returns the profile of a database provider, e.g `PostgreSQL`
+func GetDatabaseProfile(databaseProvider string) (*DatabaseProfile, error) {
+	profile, ok := DatabaseProfiles[strings.ToLower(databaseProvider)]
+	if !ok {
+		return nil, fmt.Errorf("database provider `%s` is not supported", databaseProvider)
+	}
+
+	return &profile, nil
+}
+
+// Credentials generates a random password for a new database, port zero
+// falls back to the default port of the provider.
+func (p DatabaseProfile) Credentials(name string, port int) (*DatabaseCredentials, error) {
+	password, err := GeneratePassword(24)
+	if err != nil {
+		return nil, err
+	}
+
+	if port == 0 {
+		port = p.Port
+	}
+
+	if p.Database != "" {
+		name = p.Database
+	}
+
+	return &DatabaseCredentials{
+		User:     p.User,
+		Password: password,
+		Name:     internals.RemoveNoneAlpha(name),
+		Port:     port,
+	}, nil
+}
+
+// ComposeService returns the Docker Compose service and volume of the database
+func (p DatabaseProfile) ComposeService(credentials DatabaseCredentials) (internals.ComposeService, string) {
+	volume := p.Service + "_data"
+
+	return internals.ComposeService{
+		Image:       p.Image,
+		Command:     p.Command,
+		Environment: p.Environment(credentials),
+		Ports:       []string{fmt.Sprintf("%d:%d", credentials.Port, p.Port)},
+		Volumes:     []string{volume + ":" + p.DataPath},
+	}, volume
+}
+
+// GeneratePassword returns a random alphanumeric password with at least one
+// lowercase letter, uppercase letter and digit, as required by SQL Server.
+func GeneratePassword(length int) (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	for {
+		password := make([]byte, length)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+
+			password[i] = alphabet[n.Int64()]
+		}
+
+		if strings.ContainsAny(string(password), alphabet[:26]) &&
+			strings.ContainsAny(string(password), alphabet[26:52]) &&
+			strings.ContainsAny(string(password), alphabet[52:]) {
+			return string(password), nil
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/joho/godotenv"
+	"github.com/samber/lo"
+
+	"github.com/struckchure/go-alchemy/internals"
+	"github.com/struckchure/go-alchemy/orms"
+)
+
+const migrationsDir string = "migrations"
+
+// modelMigrations are the templates of each model's table, for the orms in
+// orms.SqlMigrationOrms
+var modelMigrations map[string]string = map[string]string{
+	"User":               "migrations/users.sql",
+	"RefreshToken":       "migrations/refresh_tokens.sql",
+	"RevokedToken":       "migrations/revoked_tokens.sql",
+	"PasswordResetToken": "migrations/password_reset_tokens.sql",
+	"LinkedAccount":      "migrations/linked_accounts.sql",
+	"RecoveryCode":       "migrations/recovery_codes.sql",
+	"MagicLinkToken":     "migrations/magic_link_tokens.sql",
+	"ApiKey":             "migrations/api_keys.sql",
+	"Session":            "migrations/sessions.sql",
+	// UserEmailVerification and UserMfa add columns to users, which may already exist
+	"UserEmailVerification": "migrations/users_email_verification.sql",
+	"UserMfa":               "migrations/users_mfa.sql",
+}
+
+// ModelMigration is the SQL migration creating the tables of a component's models
+type ModelMigration struct {
+	// Name is the component the migration is named after, e.g Authentication.Login
+	Name   string
+	Models []string
+}
+
+// MigrationName turns a component id into a migration name,
+// e.g Authentication.Login becomes authentication_login
+func MigrationName(componentId string) string {
+	return strings.ToLower(strings.ReplaceAll(componentId, ".", "_"))
+}
+
+// WriteMigration creates a goose migration named `<timestamp>_<name>.sql`
+func WriteMigration(name string, up string, down string) (*string, error) {
+	err := os.MkdirAll(migrationsDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// versions are unique, migrations created within the same second get the next one
+	version := time.Now().UTC()
+	migrationPath := ""
+	for {
+		migrationPath = filepath.Join(
+			migrationsDir,
+			fmt.Sprintf("%s_%s.sql", version.Format("20060102150405"), MigrationName(name)),
+		)
+
+		matches, err := filepath.Glob(filepath.Join(migrationsDir, version.Format("20060102150405")+"_*.sql"))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			break
+		}
+
+		version = version.Add(time.Second)
+	}
+	content := fmt.Sprintf("-- +goose Up\n%s\n\n-- +goose Down\n%s\n", up, down)
+
+	err = os.WriteFile(migrationPath, []byte(content), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrationPath, nil
+}
+
+// GenerateModelMigration writes a single migration for the models that don't
+// have a table yet, it returns the migrated models.
+//
+// Nothing is generated when the orm doesn't use SQL migrations.
+func GenerateModelMigration(migration ModelMigration, values map[string]interface{}) ([]Dependency, error) {
+	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	if !lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name) {
+		return nil, nil
+	}
+
+	migratedModels := lo.FlatMap(cfg.Components, func(c Component, _ int) []string {
+		return lo.Map(c.Migrations, func(d Dependency, _ int) string { return d.Id })
+	})
+
+	models := lo.Without(migration.Models, migratedModels...)
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	var ups, downs []string
+	for _, model := range models {
+		tmplPath, ok := modelMigrations[model]
+		if !ok {
+			return nil, fmt.Errorf("model `%s` has no migration", model)
+		}
+
+		content, err := RenderTmpl(GenerateSingleTmplArgs{TmplPath: tmplPath, Values: values})
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, found := strings.Cut(*content, "-- +goose Down")
+		if !found {
+			return nil, fmt.Errorf("migration of model `%s` has no down section", model)
+		}
+
+		ups = append(ups, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(up), "-- +goose Up")))
+		downs = append(downs, strings.TrimSpace(down))
+	}
+
+	// tables are dropped in the reverse order they're created
+	slices.Reverse(downs)
+
+	migrationPath, err := WriteMigration(migration.Name, strings.Join(ups, "\n\n"), strings.Join(downs, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	color.Green("  + %s", *migrationPath)
+
+	return lo.Map(models, func(model string, _ int) Dependency {
+		return Dependency{Id: model, Path: *migrationPath}
+	}), nil
+}
+
+var ErrMigrationsNotSupported = errors.New("migrations are not supported with this orm")
+
+type MigrationArgs struct {
+	Root string
+}
+
+type MigrationCreateArgs struct {
+	Name string
+	Root string
+}
+
+type IMigrationService interface {
+	Create(MigrationCreateArgs) error
+	Up(MigrationArgs) error
+	Down(MigrationArgs) error
+	Status(MigrationArgs) error
+}
+
+type MigrationService struct{}
+
+func (m *MigrationService) readConfig(root string) (*Config, error) {
+	err := os.Chdir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !internals.FileExists("alchemy.yaml") {
+		return nil, internals.ErrAlchemyConfigNotFound
+	}
+
+	return internals.ReadYaml[Config]("alchemy.yaml")
+}
+
+// prisma runs `prisma migrate`, mongodb has no migrations with prisma
+func (m *MigrationService) prisma(cfg *Config, args ...string) error {
+	if strings.ToLower(cfg.Orm.DatabaseProvider) == "mongodb" {
+		return fmt.Errorf("%w, use `prisma db push` with MongoDB", ErrMigrationsNotSupported)
+	}
+
+	return RunCommand(nil, "go", append([]string{"run", "github.com/steebchen/prisma-client-go", "migrate"}, args...)...)
+}
+
+// goose runs a goose command against DATABASE_URL
+func (m *MigrationService) goose(cfg *Config, args ...string) error {
+	// the environment takes precedence over .env, e.g in deployment pipelines
+	databaseUrl := os.Getenv("DATABASE_URL")
+	if env, err := godotenv.Read(".env"); err == nil && databaseUrl == "" {
+		databaseUrl = env["DATABASE_URL"]
+	}
+
+	if databaseUrl == "" {
+		return errors.New("DATABASE_URL is not set")
+	}
+
+	env := []string{
+		"GOOSE_DRIVER=" + orms.GooseDrivers[cfg.Orm.DatabaseProvider],
+		"GOOSE_DBSTRING=" + databaseUrl,
+		"GOOSE_MIGRATION_DIR=" + migrationsDir,
+	}
+
+	return RunCommand(env, "go", append([]string{"run", orms.GoosePackage}, args...)...)
+}
+
+// Creates an empty migration
+func (m *MigrationService) Create(args MigrationCreateArgs) error {
+	cfg, err := m.readConfig(args.Root)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.Orm.Name == "Prisma":
+		return m.prisma(cfg, "dev", "--create-only", "--name", MigrationName(args.Name))
+	case lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name):
+		migrationPath, err := WriteMigration(args.Name, "", "")
+		if err != nil {
+			return err
+		}
+
+		color.Green("  + %s", *migrationPath)
+
+		return nil
+	default:
+		return fmt.Errorf("%w [%s]", ErrMigrationsNotSupported, cfg.Orm.Name)
+	}
+}
+
+// Applies all pending migrations
+func (m *MigrationService) Up(args MigrationArgs) error {
+	cfg, err := m.readConfig(args.Root)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.Orm.Name == "Prisma":
+		return m.prisma(cfg, "deploy")
+	case lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name):
+		return m.goose(cfg, "up")
+	default:
+		return fmt.Errorf("%w [%s]", ErrMigrationsNotSupported, cfg.Orm.Name)
+	}
+}
+
+// Rolls back the latest migration
+func (m *MigrationService) Down(args MigrationArgs) error {
+	cfg, err := m.readConfig(args.Root)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.Orm.Name == "Prisma":
+		return errors.New("prisma doesn't support down migrations, create a new migration that reverts the change instead")
+	case lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name):
+		return m.goose(cfg, "down")
+	default:
+		return fmt.Errorf("%w [%s]", ErrMigrationsNotSupported, cfg.Orm.Name)
+	}
+}
+
+// Shows which migrations have been applied
+func (m *MigrationService) Status(args MigrationArgs) error {
+	cfg, err := m.readConfig(args.Root)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.Orm.Name == "Prisma":
+		return m.prisma(cfg, "status")
+	case lo.Contains(orms.SqlMigrationOrms, cfg.Orm.Name):
+		return m.goose(cfg, "status")
+	default:
+		return fmt.Errorf("%w [%s]", ErrMigrationsNotSupported, cfg.Orm.Name)
+	}
+}
+
+func NewMigrationService() IMigrationService {
+	return &MigrationService{}
+}
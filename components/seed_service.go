@@ -0,0 +1,101 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/samber/lo"
+
+	"github.com/struckchure/go-alchemy/internals"
+	"github.com/struckchure/go-alchemy/orms"
+)
+
+type SeedArgs struct {
+	Root  string
+	Count int
+	Seed  int64
+	Reset bool
+}
+
+type ISeedService interface {
+	Seed(SeedArgs) error
+}
+
+type SeedService struct{}
+
+// Generates cmd/seed/main.go for the seeds of the installed components, then
+// runs it to insert fixture data through the generated DAOs
+func (s *SeedService) Seed(args SeedArgs) error {
+	err := os.Chdir(args.Root)
+	if err != nil {
+		return err
+	}
+
+	if !internals.FileExists("alchemy.yaml") {
+		return internals.ErrAlchemyConfigNotFound
+	}
+
+	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
+	if err != nil {
+		return err
+	}
+
+	seeds := lo.FlatMap(cfg.Components, func(c Component, _ int) []Dependency { return c.Seeds })
+	if len(seeds) == 0 {
+		return errors.New("none of the installed components have seeds, e.g add `Authentication.Register`")
+	}
+
+	moduleName, err := GetModuleName()
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{
+		"ModuleName":       *moduleName,
+		"Orm":              cfg.Orm.Name,
+		"DatabaseProvider": strings.ToLower(cfg.Orm.DatabaseProvider),
+	}
+	for _, seed := range seeds {
+		values[seed.Id] = true
+	}
+
+	dependencies := []string{"github.com/joho/godotenv"}
+	if cfg.Orm.Name == "Ent" {
+		dependencies = append(dependencies, orms.EntDrivers[cfg.Orm.DatabaseProvider]...)
+	}
+
+	for _, dependency := range dependencies {
+		cmd := exec.Command("go", "get", dependency)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.Join(err, errors.New(string(out)))
+		}
+	}
+
+	err = GenerateSingleTmpl(GenerateSingleTmplArgs{
+		TmplPath:   "cmd/seed/main.go",
+		OutputPath: "cmd/seed/main.go",
+		Values:     values,
+		GoFormat:   true,
+	})
+	if err != nil {
+		return err
+	}
+
+	color.Green("  + cmd/seed/main.go")
+
+	return RunCommand(
+		nil,
+		"go", "run", "./cmd/seed",
+		fmt.Sprintf("-count=%d", args.Count),
+		fmt.Sprintf("-seed=%d", args.Seed),
+		fmt.Sprintf("-reset=%t", args.Reset),
+	)
+}
+
+func NewSeedService() ISeedService {
+	return &SeedService{}
+}
@@ -3,5 +3,5 @@ package components
 type IAlchemyComponent interface {
 	Setup(component string) (func() error, error)
 	PreSetup() error
-	PostSetup() error
+	PostSetup(component string) error
 }
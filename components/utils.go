@@ -2,6 +2,7 @@ package components
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"go/format"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -154,34 +156,35 @@ type GenerateSingleTmplArgs struct {
 	GoFormat   bool
 }
 
-func GenerateSingleTmpl(args GenerateSingleTmplArgs) error {
+// RenderTmpl fetches and executes a template, returning the generated content
+func RenderTmpl(args GenerateSingleTmplArgs) (*string, error) {
 	var tmpl *template.Template
 	var tmplContent string
 
 	tmplPath, err := JoinURLsOrPaths(internals.ALCHEMY_BASE_DIR, args.TmplPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	args.TmplPath = tmplPath
 
 	isRemoteURL, err := IsRemoteURL(args.TmplPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tmplFileName := lo.Must(lo.Last(strings.Split(args.TmplPath, "/")))
 	if isRemoteURL {
 		content, err := ReadRemoteFile(args.TmplPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		tmplContent = *content
 	} else {
 		content, err := os.ReadFile(args.TmplPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		tmplContent = string(content)
@@ -189,12 +192,38 @@ func GenerateSingleTmpl(args GenerateSingleTmplArgs) error {
 
 	parsedTmplContent, err := internals.Parse(tmplContent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tmpl, err = template.New(tmplFileName).
 		Funcs(template.FuncMap(args.Funcs)).
 		Parse(*parsedTmplContent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the template with the provided values
+	var content bytes.Buffer
+	err = tmpl.Execute(&content, args.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	// If Go formatting is requested, format the content
+	if args.GoFormat {
+		formattedContent, err := FormatGoCode(content.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to format Go code: %w", err)
+		}
+
+		return formattedContent, nil
+	}
+
+	return lo.ToPtr(content.String()), nil
+}
+
+func GenerateSingleTmpl(args GenerateSingleTmplArgs) error {
+	content, err := RenderTmpl(args)
 	if err != nil {
 		return err
 	}
@@ -207,38 +236,10 @@ func GenerateSingleTmpl(args GenerateSingleTmplArgs) error {
 	}
 
 	// Create or overwrite the output file
-	file, err := os.Create(args.OutputPath)
+	err = os.WriteFile(args.OutputPath, []byte(*content), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create file %s: %w", args.OutputPath, err)
 	}
-	defer file.Close()
-
-	// Execute the template with the provided values
-	err = tmpl.Execute(file, args.Values)
-	if err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	// If Go formatting is requested, format the file content
-	if args.GoFormat {
-		// Read the generated file content
-		content, err := os.ReadFile(args.OutputPath)
-		if err != nil {
-			return fmt.Errorf("failed to read file for formatting: %w", err)
-		}
-
-		// Format the content using the FormatGoCode function
-		formattedContent, err := FormatGoCode(string(content))
-		if err != nil {
-			return fmt.Errorf("failed to format Go code: %w", err)
-		}
-
-		// Write the formatted content back to the file
-		err = os.WriteFile(args.OutputPath, []byte(*formattedContent), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write formatted content to file: %w", err)
-		}
-	}
 
 	return nil
 }
@@ -247,6 +248,8 @@ type GenerateMultipleTmplsArgs struct {
 	ComponentId string
 	Tmpls       []GenerateSingleTmplArgs
 	Values      map[string]interface{}
+	Compose     []ComposeDependency
+	Migration   *ModelMigration
 }
 
 func GenerateMultipleTmpls(args GenerateMultipleTmplsArgs) error {
@@ -278,14 +281,96 @@ func GenerateMultipleTmpls(args GenerateMultipleTmplsArgs) error {
 				Id:   componentName,
 				Path: tmpl.OutputPath,
 			})
+		case "Seeds":
+			newComponentConfig.Seeds = append(newComponentConfig.Seeds, Dependency{
+				Id:   componentName,
+				Path: tmpl.OutputPath,
+			})
 		}
 
 		color.Green("  + %s", tmpl.OutputPath)
 	}
 
+	if args.Migration != nil {
+		migrations, err := GenerateModelMigration(*args.Migration, args.Values)
+		if err != nil {
+			return err
+		}
+
+		newComponentConfig.Migrations = migrations
+	}
+
+	if len(args.Compose) > 0 {
+		// only what the component added is recorded, so removing it leaves the
+		// services the compose file already had
+		addedServices, addedVolumes, err := AddComposeDependencies(args.Compose)
+		if err != nil {
+			return err
+		}
+
+		newComponentConfig.ComposeServices = addedServices
+		newComponentConfig.ComposeVolumes = addedVolumes
+	}
+
 	return UpdateComponentConfig(newComponentConfig)
 }
 
+// AddComposeDependencies merges the services of a component into
+// docker-compose.yaml and adds their missing entries to .env, the services and
+// volumes it added are returned.
+//
+// Services already in docker-compose.yaml are kept as they are, and so are
+// their volumes, which are only added along with their service.
+func AddComposeDependencies(dependencies []ComposeDependency) ([]string, []string, error) {
+	compose := internals.ComposeFile{
+		Name:     GetDirectoryName(),
+		Services: map[string]internals.ComposeService{},
+	}
+
+	for _, dependency := range dependencies {
+		compose.Services[dependency.Name] = dependency.Service
+	}
+
+	addedServices, _, err := internals.MergeCompose("docker-compose.yaml", compose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	volumes := internals.ComposeFile{Volumes: map[string]internals.ComposeVolume{}}
+	for _, dependency := range dependencies {
+		if !lo.Contains(addedServices, dependency.Name) {
+			continue
+		}
+
+		for _, volume := range dependency.Volumes {
+			volumes.Volumes[volume] = internals.ComposeVolume{}
+		}
+	}
+
+	addedVolumes := []string{}
+	if len(volumes.Volumes) > 0 {
+		_, addedVolumes, err = internals.MergeCompose("docker-compose.yaml", volumes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, service := range addedServices {
+		color.Green("  + docker-compose.yaml [%s]", service)
+	}
+
+	for _, dependency := range dependencies {
+		for _, key := range internals.SortedKeys(dependency.Env) {
+			err := internals.WriteEnvVarIfMissing(".env", key, dependency.Env[key])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return addedServices, addedVolumes, nil
+}
+
 func UpdateComponentConfig(componentConfig Component) error {
 	cfg, err := internals.ReadYaml[Config]("alchemy.yaml")
 	if err != nil {
@@ -300,6 +385,10 @@ func UpdateComponentConfig(componentConfig Component) error {
 	if componentExists {
 		componentConfig.Models = lo.Uniq(append(componentConfig.Models, currentComponentConfig.Models...))
 		componentConfig.Services = lo.Uniq(append(componentConfig.Services, currentComponentConfig.Services...))
+		componentConfig.Seeds = lo.Uniq(append(componentConfig.Seeds, currentComponentConfig.Seeds...))
+		componentConfig.ComposeServices = lo.Uniq(append(componentConfig.ComposeServices, currentComponentConfig.ComposeServices...))
+		componentConfig.ComposeVolumes = lo.Uniq(append(componentConfig.ComposeVolumes, currentComponentConfig.ComposeVolumes...))
+		componentConfig.Migrations = lo.Uniq(append(componentConfig.Migrations, currentComponentConfig.Migrations...))
 
 		_, idx, ok := lo.FindIndexOf(
 			cfg.Components,
@@ -320,6 +409,11 @@ func MakeValues(componentId string, values map[string]interface{}) (*map[string]
 	if err != nil {
 		return nil, err
 	}
+
+	values["DatabaseProvider"] = strings.ToLower(cfg.Orm.DatabaseProvider)
+	values["Timestamps"] = cfg.Models.Timestamps
+	values["SoftDelete"] = cfg.Models.SoftDelete
+
 	currentComponentConfig, componentExists := lo.Find(
 		cfg.Components,
 		func(c Component) bool { return c.Id == componentId },
@@ -336,3 +430,18 @@ func MakeValues(componentId string, values map[string]interface{}) (*map[string]
 
 	return &values, nil
 }
+
+// RunCommand runs a command and prints its output
+func RunCommand(env []string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Join(err, errors.New(string(out)))
+	}
+
+	fmt.Print(string(out))
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ApiKey holds the schema definition for the ApiKey entity.
+type ApiKey struct {
+	ent.Schema
+}
+
+func (ApiKey) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "api_keys"},
+	}
+}
+
+func (ApiKey) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("user_id", uuid.UUID{}),
+		field.String("name"),
+		field.String("prefix").Unique(),
+		field.String("secret_hash").Sensitive(),
+		field.String("scope").Default(""),
+		field.Time("expires_at").Optional().Nillable(),
+		field.Time("last_used_at").Optional().Nillable(),
+		field.Bool("revoked").Default(false),
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+	}
+}
+
+func (ApiKey) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).Ref("api_keys").Field("user_id").Unique().Required(),
+	}
+}
+
+func (ApiKey) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("user_id"),
+	}
+}
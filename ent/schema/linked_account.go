@@ -0,0 +1,52 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// LinkedAccount holds the schema definition for the LinkedAccount entity.
+type LinkedAccount struct {
+	ent.Schema
+}
+
+func (LinkedAccount) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "linked_accounts"},
+	}
+}
+
+func (LinkedAccount) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("user_id", uuid.UUID{}),
+		field.String("provider"),
+		field.String("provider_user_id"),
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+	}
+}
+
+func (LinkedAccount) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).Ref("linked_accounts").Field("user_id").Unique().Required(),
+	}
+}
+
+func (LinkedAccount) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("provider", "provider_user_id").Unique(),
+		index.Fields("user_id"),
+	}
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// MagicLinkToken holds the schema definition for the MagicLinkToken entity.
+// Tokens belong to an email instead of a user, as the user may be registered
+// once the token is redeemed.
+type MagicLinkToken struct {
+	ent.Schema
+}
+
+func (MagicLinkToken) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "magic_link_tokens"},
+	}
+}
+
+func (MagicLinkToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.String("email"),
+		field.String("token_hash").Unique().Sensitive(),
+		field.Time("expires_at"),
+		field.Bool("used").Default(false),
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+	}
+}
+
+func (MagicLinkToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("email"),
+	}
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken holds the schema definition for the PasswordResetToken entity.
+type PasswordResetToken struct {
+	ent.Schema
+}
+
+func (PasswordResetToken) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "password_reset_tokens"},
+	}
+}
+
+func (PasswordResetToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("user_id", uuid.UUID{}),
+		field.String("token_hash").Unique().Sensitive(),
+		field.Time("expires_at"),
+		field.Bool("used").Default(false),
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+	}
+}
+
+func (PasswordResetToken) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).Ref("password_reset_tokens").Field("user_id").Unique().Required(),
+	}
+}
+
+func (PasswordResetToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("user_id"),
+	}
+}
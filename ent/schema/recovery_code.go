@@ -0,0 +1,51 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// RecoveryCode holds the schema definition for the RecoveryCode entity.
+type RecoveryCode struct {
+	ent.Schema
+}
+
+func (RecoveryCode) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "recovery_codes"},
+	}
+}
+
+func (RecoveryCode) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("user_id", uuid.UUID{}),
+		field.String("code_hash").Sensitive(),
+		field.Bool("used").Default(false),
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+	}
+}
+
+func (RecoveryCode) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).Ref("recovery_codes").Field("user_id").Unique().Required(),
+	}
+}
+
+func (RecoveryCode) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("user_id"),
+	}
+}
@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// RevokedToken holds the schema definition for the RevokedToken entity.
+//
+// It has no edge to User, so revocations outlive the users they belong to.
+type RevokedToken struct {
+	ent.Schema
+}
+
+func (RevokedToken) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "revoked_tokens"},
+	}
+}
+
+func (RevokedToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("user_id", uuid.UUID{}),
+		field.String("jti").Optional().Nillable(),
+		field.Time("revoked_at"),
+		field.Time("expires_at"),
+	}
+}
+
+func (RevokedToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("jti"),
+		index.Fields("user_id"),
+	}
+}
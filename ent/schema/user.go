@@ -0,0 +1,79 @@
+package schema
+
+import (
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	// @alchemy block {{- if or .RefreshToken .PasswordResetToken .LinkedAccount .RecoveryCode .ApiKey .Session }}
+	"entgo.io/ent/schema/edge"
+	// @alchemy block {{- end }}
+	"entgo.io/ent/schema/field"
+	"github.com/google/uuid"
+)
+
+// User holds the schema definition for the User entity.
+type User struct {
+	ent.Schema
+}
+
+func (User) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "users"},
+	}
+}
+
+func (User) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.String("first_name").Optional().Nillable(),
+		field.String("last_name").Optional().Nillable(),
+		field.String("email").Unique(),
+		field.String("password").Sensitive(),
+		// @alchemy block {{- if .EmailVerification }}
+		field.Time("email_verified_at").Optional().Nillable(),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+		field.String("mfa_secret").Optional().Nillable().Sensitive(),
+		field.Bool("mfa_enabled").Default(false),
+		field.Int64("mfa_totp_step").Default(0),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Timestamps }}
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .SoftDelete }}
+		field.Time("deleted_at").Optional().Nillable(),
+		// @alchemy block {{- end }}
+	}
+}
+
+// @alchemy block {{- if or .RefreshToken .PasswordResetToken .LinkedAccount .RecoveryCode .ApiKey .Session }}
+
+func (User) Edges() []ent.Edge {
+	return []ent.Edge{
+		// @alchemy block {{- if .RefreshToken }}
+		edge.To("refresh_tokens", RefreshToken.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .PasswordResetToken }}
+		edge.To("password_reset_tokens", PasswordResetToken.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .LinkedAccount }}
+		edge.To("linked_accounts", LinkedAccount.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .RecoveryCode }}
+		edge.To("recovery_codes", RecoveryCode.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .ApiKey }}
+		edge.To("api_keys", ApiKey.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Session }}
+		edge.To("sessions", Session.Type).Annotations(entsql.OnDelete(entsql.Cascade)),
+		// @alchemy block {{- end }}
+	}
+}
+
+// @alchemy block {{- end }}
@@ -0,0 +1,173 @@
+package internals
+
+import (
+	"errors"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ComposeService struct {
+	Image       string   `yaml:"image"`
+	Command     string   `yaml:"command,omitempty"`
+	Environment []string `yaml:"environment,omitempty"`
+	Ports       []string `yaml:"ports,omitempty"`
+	Volumes     []string `yaml:"volumes,omitempty"`
+}
+
+type ComposeVolume struct{}
+
+type ComposeFile struct {
+	Name     string                    `yaml:"name"`
+	Services map[string]ComposeService `yaml:"services"`
+	Volumes  map[string]ComposeVolume  `yaml:"volumes,omitempty"`
+}
+
+// readComposeFile reads fileName as a yaml document, an empty document is
+// returned when the file doesn't exist yet.
+func readComposeFile(fileName string) (*yaml.Node, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	document := yaml.Node{}
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		document = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New(fileName + " is not a valid compose file")
+	}
+
+	return &document, nil
+}
+
+// mappingValue returns the value of key in a mapping node, creating it with
+// kind when create is true and the key doesn't exist.
+func mappingValue(mapping *yaml.Node, key string, kind yaml.Kind, create bool) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	if !create {
+		return nil
+	}
+
+	value := &yaml.Node{Kind: kind}
+	if kind == yaml.MappingNode {
+		value.Tag = "!!map"
+	}
+
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+func removeMappingKeys(mapping *yaml.Node, keys []string) {
+	if mapping == nil {
+		return
+	}
+
+	content := []*yaml.Node{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !slices.Contains(keys, mapping.Content[i].Value) {
+			content = append(content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+
+	mapping.Content = content
+}
+
+func writeComposeFile(fileName string, document *yaml.Node) error {
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := yaml.NewEncoder(file)
+	encoder.SetIndent(2)
+
+	return encoder.Encode(document)
+}
+
+// MergeCompose adds the services and volumes of compose to the compose file at
+// fileName, the file is created when it doesn't exist.
+//
+// Everything already in the file is left as it is, including services and
+// volumes with the same name, the names of the services and volumes that were
+// added are returned.
+func MergeCompose(fileName string, compose ComposeFile) ([]string, []string, error) {
+	document, err := readComposeFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := document.Content[0]
+	if compose.Name != "" {
+		name := mappingValue(root, "name", yaml.ScalarNode, true)
+		if name.Value == "" {
+			name.Value = compose.Name
+		}
+	}
+
+	addedServices := []string{}
+	services := mappingValue(root, "services", yaml.MappingNode, true)
+	for _, name := range SortedKeys(compose.Services) {
+		if mappingValue(services, name, yaml.MappingNode, false) != nil {
+			continue
+		}
+
+		service := mappingValue(services, name, yaml.MappingNode, true)
+		err = service.Encode(compose.Services[name])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		addedServices = append(addedServices, name)
+	}
+
+	addedVolumes := []string{}
+	if len(compose.Volumes) > 0 {
+		volumes := mappingValue(root, "volumes", yaml.MappingNode, true)
+		for _, name := range SortedKeys(compose.Volumes) {
+			if mappingValue(volumes, name, yaml.MappingNode, false) != nil {
+				continue
+			}
+
+			mappingValue(volumes, name, yaml.MappingNode, true)
+			addedVolumes = append(addedVolumes, name)
+		}
+	}
+
+	return addedServices, addedVolumes, writeComposeFile(fileName, document)
+}
+
+// RemoveCompose removes services and volumes from the compose file at fileName
+func RemoveCompose(fileName string, services []string, volumes []string) error {
+	if !FileExists(fileName) {
+		return nil
+	}
+
+	document, err := readComposeFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	root := document.Content[0]
+	removeMappingKeys(mappingValue(root, "services", yaml.MappingNode, false), services)
+	removeMappingKeys(mappingValue(root, "volumes", yaml.MappingNode, false), volumes)
+
+	return writeComposeFile(fileName, document)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	"errors"
@@ -71,6 +72,31 @@ func WriteEnvVar(envFilePath, key, value string) error {
 	return nil
 }
 
+// WriteEnvVarIfMissing adds an environment variable to a .env file, unless the
+// file already has a value for key.
+func WriteEnvVarIfMissing(envFilePath, key, value string) error {
+	content, err := os.ReadFile(envFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, key+"=") {
+			return nil
+		}
+	}
+
+	return WriteEnvVar(envFilePath, key, value)
+}
+
+// SortedKeys returns the keys of m in ascending order
+func SortedKeys[T any](m map[string]T) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+
+	return keys
+}
+
 func RemoveNoneAlpha(i string) string {
 	re := regexp.MustCompile(`[^\w]+`) // Matches anything that's not a word character
 	return re.ReplaceAllString(i, "")
@@ -92,7 +118,7 @@ func ReadYaml[T any](fileName string) (*T, error) {
 }
 
 func WriteYaml(fileName string, data interface{}) error {
-	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0644)
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
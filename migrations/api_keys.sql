@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE api_keys (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+  user_id VARCHAR(36) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- end }}
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(64) NOT NULL UNIQUE,
+  secret_hash VARCHAR(64) NOT NULL,
+  scope VARCHAR(1024) NOT NULL DEFAULT '',
+  expires_at {{ template "timestamp" . }} NULL,
+  last_used_at {{ template "timestamp" . }} NULL,
+  revoked {{ if eq .DatabaseProvider "sqlserver" }}BIT{{ else }}BOOLEAN{{ end }} NOT NULL DEFAULT {{ if eq .DatabaseProvider "sqlserver" }}0{{ else }}FALSE{{ end }}{{ if .Timestamps }},
+  created_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP{{ end }}
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+
+-- +goose Down
+DROP TABLE api_keys;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
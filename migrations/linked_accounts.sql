@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE linked_accounts (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+  user_id VARCHAR(36) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- end }}
+  provider VARCHAR(255) NOT NULL,
+  provider_user_id VARCHAR(255) NOT NULL{{ if .Timestamps }},
+  created_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP{{ end }},
+  CONSTRAINT linked_accounts_provider_user_idx UNIQUE (provider, provider_user_id)
+);
+
+CREATE INDEX linked_accounts_user_id_idx ON linked_accounts (user_id);
+
+-- +goose Down
+DROP TABLE linked_accounts;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE magic_link_tokens (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+{{- end }}
+  email VARCHAR(255) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  expires_at {{ template "timestamp" . }} NOT NULL,
+  used {{ if eq .DatabaseProvider "sqlserver" }}BIT{{ else }}BOOLEAN{{ end }} NOT NULL DEFAULT {{ if eq .DatabaseProvider "sqlserver" }}0{{ else }}FALSE{{ end }}{{ if .Timestamps }},
+  created_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP{{ end }}
+);
+
+CREATE INDEX magic_link_tokens_email_idx ON magic_link_tokens (email);
+
+-- +goose Down
+DROP TABLE magic_link_tokens;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE recovery_codes (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+  user_id VARCHAR(36) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+{{- end }}
+  code_hash VARCHAR(64) NOT NULL,
+  used {{ if eq .DatabaseProvider "sqlserver" }}BIT{{ else }}BOOLEAN{{ end }} NOT NULL DEFAULT {{ if eq .DatabaseProvider "sqlserver" }}0{{ else }}FALSE{{ end }}{{ if .Timestamps }},
+  created_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP{{ end }}
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+-- +goose Down
+DROP TABLE recovery_codes;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
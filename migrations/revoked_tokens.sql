@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE revoked_tokens (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL,
+{{- else if eq .DatabaseProvider "clickhouse" }}
+  id String,
+  user_id String,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+  user_id VARCHAR(36) NOT NULL,
+{{- end }}
+{{- if eq .DatabaseProvider "clickhouse" }}
+  jti Nullable(String),
+  revoked_at DateTime64(3),
+  expires_at DateTime64(3)
+) ENGINE = MergeTree ORDER BY id;
+{{- else }}
+  jti VARCHAR(64),
+  revoked_at {{ template "timestamp" . }} NOT NULL,
+  expires_at {{ template "timestamp" . }} NOT NULL
+);
+
+CREATE INDEX revoked_tokens_jti_idx ON revoked_tokens (jti);
+CREATE INDEX revoked_tokens_user_id_idx ON revoked_tokens (user_id);
+{{- end }}
+
+-- +goose Down
+DROP TABLE revoked_tokens;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
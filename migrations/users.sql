@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE users (
+{{- if eq .DatabaseProvider "postgresql" }}
+  id UUID PRIMARY KEY,
+{{- else if eq .DatabaseProvider "clickhouse" }}
+  id String,
+{{- else }}
+  id VARCHAR(36) PRIMARY KEY,
+{{- end }}
+{{- if eq .DatabaseProvider "clickhouse" }}
+  first_name Nullable(String),
+  last_name Nullable(String),
+  email String,
+  password String{{ if .Timestamps }},
+  created_at DateTime64(3) DEFAULT now64(3),
+  updated_at DateTime64(3) DEFAULT now64(3){{ end }}{{ if .SoftDelete }},
+  deleted_at Nullable(DateTime64(3)){{ end }}
+) ENGINE = MergeTree ORDER BY id;
+{{- else }}
+  first_name VARCHAR(255),
+  last_name VARCHAR(255),
+  email VARCHAR(255) NOT NULL UNIQUE,
+  password VARCHAR(255) NOT NULL{{ if .Timestamps }},
+  created_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at {{ template "timestamp" . }} NOT NULL DEFAULT CURRENT_TIMESTAMP{{ end }}{{ if .SoftDelete }},
+  deleted_at {{ template "timestamp" . }} NULL{{ end }}
+);
+{{- end }}
+
+-- +goose Down
+DROP TABLE users;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else if eq .DatabaseProvider "sqlserver" }}DATETIME2
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
@@ -0,0 +1,17 @@
+-- +goose Up
+{{- if eq .DatabaseProvider "clickhouse" }}
+ALTER TABLE users ADD COLUMN email_verified_at Nullable(DateTime64(3));
+{{- else if eq .DatabaseProvider "sqlserver" }}
+ALTER TABLE users ADD email_verified_at DATETIME2 NULL;
+{{- else }}
+ALTER TABLE users ADD COLUMN email_verified_at {{ template "timestamp" . }} NULL;
+{{- end }}
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN email_verified_at;
+{{- define "timestamp" }}
+{{- if eq .DatabaseProvider "postgresql" }}TIMESTAMPTZ
+{{- else if eq .DatabaseProvider "mysql" }}DATETIME(3)
+{{- else }}TIMESTAMP
+{{- end }}
+{{- end }}
@@ -0,0 +1,19 @@
+-- +goose Up
+{{- if eq .DatabaseProvider "sqlserver" }}
+ALTER TABLE users ADD mfa_secret VARCHAR(255) NULL;
+ALTER TABLE users ADD mfa_enabled BIT NOT NULL CONSTRAINT users_mfa_enabled_default DEFAULT 0;
+ALTER TABLE users ADD mfa_totp_step BIGINT NOT NULL CONSTRAINT users_mfa_totp_step_default DEFAULT 0;
+{{- else }}
+ALTER TABLE users ADD COLUMN mfa_secret VARCHAR(255) NULL;
+ALTER TABLE users ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN mfa_totp_step BIGINT NOT NULL DEFAULT 0;
+{{- end }}
+
+-- +goose Down
+{{- if eq .DatabaseProvider "sqlserver" }}
+ALTER TABLE users DROP CONSTRAINT users_mfa_totp_step_default;
+ALTER TABLE users DROP CONSTRAINT users_mfa_enabled_default;
+{{- end }}
+ALTER TABLE users DROP COLUMN mfa_totp_step;
+ALTER TABLE users DROP COLUMN mfa_enabled;
+ALTER TABLE users DROP COLUMN mfa_secret;
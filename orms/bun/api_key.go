@@ -0,0 +1,150 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	bun.BaseModel `bun:"table:api_keys"`
+
+	Id     string `json:"id" bun:"id,pk"`
+	UserId string `json:"userId" bun:"user_id"`
+	Name   string `json:"name" bun:"name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix" bun:"prefix,unique"`
+	SecretHash string     `json:"-" bun:"secret_hash"`
+	Scope      string     `json:"scope" bun:"scope"`
+	ExpiresAt  *time.Time `json:"expiresAt" bun:"expires_at,nullzero"`
+	LastUsedAt *time.Time `json:"lastUsedAt" bun:"last_used_at,nullzero"`
+	Revoked    bool       `json:"revoked" bun:"revoked,notnull"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	client *bun.DB
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	apiKey := &ApiKey{
+		Id:         uuid.NewString(),
+		UserId:     payload.UserId,
+		Name:       payload.Name,
+		Prefix:     payload.Prefix,
+		SecretHash: payload.SecretHash,
+		Scope:      payload.Scope,
+		ExpiresAt:  payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(apiKey).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (*ApiKey, error) {
+	apiKey := new(ApiKey)
+	err := txOrClient(ctx, r.client).NewSelect().Model(apiKey).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	apiKey := new(ApiKey)
+	err := txOrClient(ctx, r.client).NewSelect().Model(apiKey).Where("prefix = ?", prefix).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	apiKeys := []ApiKey{}
+	err := txOrClient(ctx, r.client).NewSelect().Model(&apiKeys).Where("user_id = ?", userId).Order("name").Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	result, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*ApiKey)(nil)).
+		Set("revoked = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("id = ?", id).
+		Where("revoked = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*ApiKey)(nil)).
+		Set("last_used_at = ?", lastUsedAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewApiKeyDao(client *bun.DB) IApiKeyDao {
+	return &ApiKeyDao{client: client}
+}
@@ -0,0 +1,60 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"database/sql"
+	"os"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	_ "github.com/microsoft/go-mssqldb"
+	"github.com/uptrace/bun/dialect/mssqldialect"
+	// @alchemy block {{- end }}
+)
+
+// NewDB opens a bun database from the DATABASE_URL environment variable
+func NewDB() (*bun.DB, error) {
+	dsn := os.Getenv("DATABASE_URL")
+
+	var (
+		sqldb   *sql.DB
+		dialect schema.Dialect
+		err     error
+	)
+
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	sqldb = sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+	dialect = pgdialect.New()
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	sqldb, err = sql.Open("mysql", dsn)
+	dialect = mysqldialect.New()
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	sqldb, err = sql.Open(sqliteshim.ShimName, dsn)
+	dialect = sqlitedialect.New()
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	sqldb, err = sql.Open("sqlserver", dsn)
+	dialect = mssqldialect.New()
+	// @alchemy block {{- end }}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bun.NewDB(sqldb, dialect), nil
+}
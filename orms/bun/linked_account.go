@@ -0,0 +1,98 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	bun.BaseModel `bun:"table:linked_accounts"`
+
+	Id             string `json:"id" bun:"id,pk"`
+	UserId         string `json:"userId" bun:"user_id"`
+	Provider       string `json:"provider" bun:"provider,unique:linked_accounts_provider_user_idx"`
+	ProviderUserId string `json:"providerUserId" bun:"provider_user_id,unique:linked_accounts_provider_user_idx"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	client *bun.DB
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	linkedAccount := &LinkedAccount{
+		Id:             uuid.NewString(),
+		UserId:         payload.UserId,
+		Provider:       payload.Provider,
+		ProviderUserId: payload.ProviderUserId,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, l.client).NewInsert().Model(linkedAccount).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccount, nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error) {
+	linkedAccount := new(LinkedAccount)
+	err := txOrClient(ctx, l.client).NewSelect().
+		Model(linkedAccount).
+		Where("provider = ?", provider).
+		Where("provider_user_id = ?", providerUserId).
+		Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccount, nil
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, userId string) ([]LinkedAccount, error) {
+	linkedAccounts := []LinkedAccount{}
+	err := txOrClient(ctx, l.client).NewSelect().Model(&linkedAccounts).Where("user_id = ?", userId).Order("provider").Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, l.client).NewDelete().Model((*LinkedAccount)(nil)).Where("id = ?", id).Exec(ctx)
+	return translateError(err)
+}
+
+func NewLinkedAccountDao(client *bun.DB) ILinkedAccountDao {
+	return &LinkedAccountDao{client: client}
+}
@@ -0,0 +1,119 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	bun.BaseModel `bun:"table:magic_link_tokens"`
+
+	Id        string    `json:"id" bun:"id,pk"`
+	Email     string    `json:"email" bun:"email"`
+	TokenHash string    `json:"-" bun:"token_hash,unique"`
+	ExpiresAt time.Time `json:"expiresAt" bun:"expires_at"`
+	Used      bool      `json:"used" bun:"used,notnull"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	client *bun.DB
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	magicLinkToken := &MagicLinkToken{
+		Id:        uuid.NewString(),
+		Email:     payload.Email,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(magicLinkToken).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return magicLinkToken, nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*MagicLinkToken, error) {
+	magicLinkToken := new(MagicLinkToken)
+	err := txOrClient(ctx, r.client).NewSelect().Model(magicLinkToken).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return magicLinkToken, nil
+}
+
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	result, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*MagicLinkToken)(nil)).
+		Set("used = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("id = ?", id).
+		Where("used = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	_, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*MagicLinkToken)(nil)).
+		Set("used = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("email = ?", email).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewMagicLinkTokenDao(client *bun.DB) IMagicLinkTokenDao {
+	return &MagicLinkTokenDao{client: client}
+}
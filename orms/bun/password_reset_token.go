@@ -0,0 +1,119 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	bun.BaseModel `bun:"table:password_reset_tokens"`
+
+	Id        string    `json:"id" bun:"id,pk"`
+	UserId    string    `json:"userId" bun:"user_id"`
+	TokenHash string    `json:"-" bun:"token_hash,unique"`
+	ExpiresAt time.Time `json:"expiresAt" bun:"expires_at"`
+	Used      bool      `json:"used" bun:"used,notnull"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	client *bun.DB
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	passwordResetToken := &PasswordResetToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(passwordResetToken).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return passwordResetToken, nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	passwordResetToken := new(PasswordResetToken)
+	err := txOrClient(ctx, r.client).NewSelect().Model(passwordResetToken).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return passwordResetToken, nil
+}
+
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	result, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*PasswordResetToken)(nil)).
+		Set("used = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("id = ?", id).
+		Where("used = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, userId string) error {
+	_, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*PasswordResetToken)(nil)).
+		Set("used = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("user_id = ?", userId).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewPasswordResetTokenDao(client *bun.DB) IPasswordResetTokenDao {
+	return &PasswordResetTokenDao{client: client}
+}
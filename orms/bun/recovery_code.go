@@ -0,0 +1,103 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes"`
+
+	Id       string `json:"id" bun:"id,pk"`
+	UserId   string `json:"userId" bun:"user_id"`
+	CodeHash string `json:"-" bun:"code_hash"`
+	Used     bool   `json:"used" bun:"used,notnull"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	client *bun.DB
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	recoveryCode := &RecoveryCode{
+		Id:       uuid.NewString(),
+		UserId:   payload.UserId,
+		CodeHash: payload.CodeHash,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(recoveryCode).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return recoveryCode, nil
+}
+
+func (r *RecoveryCodeDao) Use(ctx context.Context, userId string, codeHash string) error {
+	result, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*RecoveryCode)(nil)).
+		Set("used = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("user_id = ?", userId).
+		Where("code_hash = ?", codeHash).
+		Where("used = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	_, err := txOrClient(ctx, r.client).NewDelete().Model((*RecoveryCode)(nil)).Where("user_id = ?", userId).Exec(ctx)
+	return translateError(err)
+}
+
+func NewRecoveryCodeDao(client *bun.DB) IRecoveryCodeDao {
+	return &RecoveryCodeDao{client: client}
+}
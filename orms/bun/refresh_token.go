@@ -0,0 +1,121 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	bun.BaseModel `bun:"table:refresh_tokens"`
+
+	Id        string    `json:"id" bun:"id,pk"`
+	UserId    string    `json:"userId" bun:"user_id"`
+	FamilyId  string    `json:"familyId" bun:"family_id"`
+	TokenHash string    `json:"-" bun:"token_hash,unique"`
+	ExpiresAt time.Time `json:"expiresAt" bun:"expires_at"`
+	Revoked   bool      `json:"revoked" bun:"revoked,notnull"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	client *bun.DB
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	refreshToken := &RefreshToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		FamilyId:  payload.FamilyId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(refreshToken).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return refreshToken, nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	refreshToken := new(RefreshToken)
+	err := txOrClient(ctx, r.client).NewSelect().Model(refreshToken).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return refreshToken, nil
+}
+
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	result, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*RefreshToken)(nil)).
+		Set("revoked = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("id = ?", id).
+		Where("revoked = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	_, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*RefreshToken)(nil)).
+		Set("revoked = ?", true).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("family_id = ?", familyId).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewRefreshTokenDao(client *bun.DB) IRefreshTokenDao {
+	return &RefreshTokenDao{client: client}
+}
@@ -0,0 +1,81 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	bun.BaseModel `bun:"table:revoked_tokens"`
+
+	Id        string    `json:"id" bun:"id,pk"`
+	UserId    string    `json:"userId" bun:"user_id"`
+	Jti       *string   `json:"jti" bun:"jti"`
+	RevokedAt time.Time `json:"revokedAt" bun:"revoked_at"`
+	ExpiresAt time.Time `json:"expiresAt" bun:"expires_at"`
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	client *bun.DB
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	revokedToken := &RevokedToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		Jti:       payload.Jti,
+		RevokedAt: time.Now(),
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(revokedToken).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return revokedToken, nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	count, err := txOrClient(ctx, r.client).NewSelect().
+		Model((*RevokedToken)(nil)).
+		Where("jti = ?", jti).
+		WhereOr("jti IS NULL AND user_id = ? AND revoked_at > ?", userId, issuedAt).
+		Count(ctx)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).NewDelete().Model((*RevokedToken)(nil)).Where("expires_at < ?", time.Now()).Exec(ctx)
+	return translateError(err)
+}
+
+func NewRevokedTokenDao(client *bun.DB) IRevokedTokenDao {
+	return &RevokedTokenDao{client: client}
+}
@@ -0,0 +1,105 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	Id         string    `json:"id" bun:"id,pk"`
+	UserId     string    `json:"userId" bun:"user_id"`
+	TokenHash  string    `json:"-" bun:"token_hash,unique"`
+	ExpiresAt  time.Time `json:"expiresAt" bun:"expires_at"`
+	LastSeenAt time.Time `json:"lastSeenAt" bun:"last_seen_at"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	client *bun.DB
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	session := &Session{
+		Id:         uuid.NewString(),
+		UserId:     payload.UserId,
+		TokenHash:  payload.TokenHash,
+		ExpiresAt:  payload.ExpiresAt,
+		LastSeenAt: payload.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).NewInsert().Model(session).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return session, nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	session := new(Session)
+	err := txOrClient(ctx, r.client).NewSelect().Model(session).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return session, nil
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	_, err := txOrClient(ctx, r.client).NewUpdate().
+		Model((*Session)(nil)).
+		Set("last_seen_at = ?", lastSeenAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, r.client).NewDelete().Model((*Session)(nil)).Where("id = ?", id).Exec(ctx)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	_, err := txOrClient(ctx, r.client).NewDelete().Model((*Session)(nil)).Where("user_id = ?", userId).Exec(ctx)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).NewDelete().Model((*Session)(nil)).Where("expires_at < ?", time.Now()).Exec(ctx)
+	return translateError(err)
+}
+
+func NewSessionDao(client *bun.DB) ISessionDao {
+	return &SessionDao{client: client}
+}
@@ -0,0 +1,44 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type txKey struct{}
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. The transaction is rolled back if fn returns an
+	// error, nested calls join the outer transaction.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client *bun.DB
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return fn(ctx)
+	}
+
+	return t.client.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// txOrClient returns the transaction of ctx, or client if ctx has none
+func txOrClient(ctx context.Context, client *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+
+	return client
+}
+
+func NewTxManager(client *bun.DB) ITxManager {
+	return &TxManager{client: client}
+}
@@ -0,0 +1,313 @@
+// @alchemy replace package dao
+package bun
+
+import (
+	"context"
+	// @alchemy block {{- if or .Timestamps .SoftDelete .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	"github.com/uptrace/bun"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type User struct {
+	bun.BaseModel `bun:"table:users"`
+
+	Id        string  `json:"id" bun:"id,pk"`
+	FirstName *string `json:"firstName" bun:"first_name"`
+	LastName  *string `json:"lastName" bun:"last_name"`
+	Email     string  `json:"email" bun:"email,unique"`
+	Password  string  `json:"-" bun:"password"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt" bun:"email_verified_at,nullzero"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-" bun:"mfa_secret,nullzero"`
+	MfaEnabled bool    `json:"mfaEnabled" bun:"mfa_enabled,notnull,default:false"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-" bun:"mfa_totp_step,notnull,default:0"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `json:"deletedAt" bun:"deleted_at,soft_delete,nullzero"`
+	// @alchemy block {{- end }}
+}
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        "id",
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+type IUserDao interface {
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
+	// @alchemy block {{- end }}
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
+	// @alchemy block {{- end }}
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
+	// @alchemy block {{- end }}
+}
+
+type UserDao struct {
+	client *bun.DB
+}
+
+func (u *UserDao) filtered(query *bun.SelectQuery, params ListParams) *bun.SelectQuery {
+	// @alchemy block {{- if .SoftDelete }}
+	if params.WithDeleted {
+		query = query.WhereAllWithDeleted()
+	}
+
+	// @alchemy block {{ end }}
+	condition, args := SqlFilters(params.Filters, userFields)
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+
+	return query
+}
+
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	params = *normalizedParams
+
+	total, err := u.filtered(txOrClient(ctx, u.client).NewSelect().Model((*User)(nil)), params).Count(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	users := []User{}
+	query := u.filtered(txOrClient(ctx, u.client).NewSelect().Model(&users), params)
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		condition, args := SqlKeyset(params.Sort, values, userFields)
+		query = query.Where(condition, args...)
+	} else {
+		query = query.Offset(params.Offset)
+	}
+
+	err = query.OrderExpr(SqlOrderBy(params.Sort, userFields)).Limit(params.Limit + 1).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return NewPage(users, int64(total), params)
+}
+
+func (u *UserDao) Get(ctx context.Context, id string) (*User, error) {
+	user := new(User)
+	err := txOrClient(ctx, u.client).NewSelect().Model(user).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return user, err
+}
+
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (*User, error) {
+	user := new(User)
+	err := txOrClient(ctx, u.client).NewSelect().Model(user).Where("email = ?", email).Scan(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return user, err
+}
+
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+
+type UserCreatePayload struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Email     string  `json:"email,omitempty"`
+	Password  string  `json:"password,omitempty"`
+}
+
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
+	user := &User{
+		Id:        uuid.NewString(),
+		FirstName: payload.FirstName,
+		LastName:  payload.LastName,
+		Email:     payload.Email,
+		Password:  payload.Password,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, u.client).NewInsert().Model(user).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return user, err
+}
+
+// @alchemy block {{- end }}
+
+type UserUpdatePayload struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Email     *string `json:"email,omitempty"`
+	Password  *string `json:"password,omitempty"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"mfaSecret,omitempty"`
+	MfaEnabled *bool   `json:"mfaEnabled,omitempty"`
+	// @alchemy block {{- end }}
+}
+
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	columns := map[string]*string{
+		"first_name": payload.FirstName,
+		"last_name":  payload.LastName,
+		"email":      payload.Email,
+		"password":   payload.Password,
+		// @alchemy block {{- if .MFA }}
+		"mfa_secret": payload.MfaSecret,
+		// @alchemy block {{- end }}
+	}
+
+	query := txOrClient(ctx, u.client).NewUpdate().Model((*User)(nil)).Where("id = ?", id)
+	changed := false
+	for column, value := range columns {
+		if value != nil {
+			query.Set("? = ?", bun.Ident(column), *value)
+			changed = true
+		}
+	}
+
+	// @alchemy block {{- if .EmailVerification }}
+
+	if payload.EmailVerifiedAt != nil {
+		query.Set("email_verified_at = ?", *payload.EmailVerifiedAt)
+		changed = true
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+
+	if payload.MfaEnabled != nil {
+		query.Set("mfa_enabled = ?", *payload.MfaEnabled)
+		changed = true
+	}
+	// @alchemy block {{- end }}
+
+	if changed {
+		// @alchemy block {{- if .Timestamps }}
+		query.Set("updated_at = ?", time.Now())
+
+		// @alchemy block {{ end }}
+		_, err := query.Exec(ctx)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	return u.Get(ctx, id)
+}
+
+// @alchemy block {{- if .MFA }}
+
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	result, err := txOrClient(ctx, u.client).NewUpdate().
+		Model((*User)(nil)).
+		Set("mfa_totp_step = ?", step).
+		// @alchemy block {{- if .Timestamps }}
+		Set("updated_at = ?", time.Now()).
+		// @alchemy block {{- end }}
+		Where("id = ?", id).
+		Where("mfa_totp_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// @alchemy block {{- end }}
+
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, u.client).NewDelete().Model((*User)(nil)).Where("id = ?", id).Exec(ctx)
+	return translateError(err)
+}
+
+// @alchemy block {{- if .SoftDelete }}
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, u.client).NewUpdate().
+		Model((*User)(nil)).
+		Set("deleted_at = NULL").
+		WhereAllWithDeleted().
+		Where("id = ?", id).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, u.client).NewDelete().Model((*User)(nil)).Where("id = ?", id).ForceDelete().Exec(ctx)
+	return translateError(err)
+}
+
+// @alchemy block {{- end }}
+
+func NewUserDao(client *bun.DB) IUserDao {
+	return &UserDao{client: client}
+}
@@ -3,11 +3,77 @@ package orms
 var OrmOptions []string = []string{
 	"Prisma",
 	"Gorm",
+	"Ent",
+	"Bun",
+	"Stdlib",
+	"Mongo",
 }
 
 var OrmMappings map[string][]string = map[string][]string{
 	"Prisma": PrismaOptions,
 	"Gorm":   GormOptions,
+	"Ent":    EntOptions,
+	"Bun":    BunOptions,
+	"Stdlib": StdlibOptions,
+	"Mongo":  MongoOptions,
+}
+
+// OrmDependencies are the go packages a generated component needs for each ORM
+var OrmDependencies map[string][]string = map[string][]string{
+	"Prisma": {"github.com/steebchen/prisma-client-go"},
+	"Gorm":   {"gorm.io/gorm", "github.com/google/uuid", "github.com/joho/godotenv"},
+	"Ent":    {"entgo.io/ent", "github.com/google/uuid"},
+	"Bun":    {"github.com/uptrace/bun", "github.com/google/uuid"},
+	"Stdlib": {"github.com/google/uuid"},
+	"Mongo":  {"go.mongodb.org/mongo-driver/v2"},
+}
+
+// GormDrivers are the gorm driver packages for each database provider
+var GormDrivers map[string][]string = map[string][]string{
+	"PostgreSQL": {"gorm.io/driver/postgres"},
+	"MySQl":      {"gorm.io/driver/mysql"},
+	"SQLite":     {"gorm.io/driver/sqlite"},
+	"SQLServer":  {"gorm.io/driver/sqlserver"},
+	"Clickhouse": {"gorm.io/driver/clickhouse"},
+}
+
+// BunDrivers are the bun dialect and database driver packages for each database provider
+var BunDrivers map[string][]string = map[string][]string{
+	"PostgreSQL": {"github.com/uptrace/bun/dialect/pgdialect", "github.com/uptrace/bun/driver/pgdriver"},
+	"MySQl":      {"github.com/uptrace/bun/dialect/mysqldialect", "github.com/go-sql-driver/mysql"},
+	"SQLite":     {"github.com/uptrace/bun/dialect/sqlitedialect", "github.com/uptrace/bun/driver/sqliteshim"},
+	"SQLServer":  {"github.com/uptrace/bun/dialect/mssqldialect", "github.com/microsoft/go-mssqldb"},
+}
+
+// StdlibDrivers are the database/sql driver packages for each database provider
+var StdlibDrivers map[string][]string = map[string][]string{
+	"PostgreSQL": {"github.com/jackc/pgx/v5"},
+	"MySQl":      {"github.com/go-sql-driver/mysql"},
+	"SQLite":     {"modernc.org/sqlite"},
+	"SQLServer":  {"github.com/microsoft/go-mssqldb"},
+}
+
+// EntDrivers are the database/sql driver packages ent opens each database provider with
+var EntDrivers map[string][]string = map[string][]string{
+	"PostgreSQL": {"github.com/lib/pq"},
+	"MySQl":      {"github.com/go-sql-driver/mysql"},
+	"SQLite":     {"github.com/mattn/go-sqlite3"},
+}
+
+// SqlMigrationOrms are the ORMs whose tables are created with versioned SQL
+// migrations, applied with goose
+var SqlMigrationOrms []string = []string{"Gorm", "Bun", "Stdlib"}
+
+// GoosePackage is run with `go run` so goose isn't added to the project's go.mod
+var GoosePackage string = "github.com/pressly/goose/v3/cmd/goose@v3.24.1"
+
+// GooseDrivers are the goose driver names for each database provider
+var GooseDrivers map[string]string = map[string]string{
+	"PostgreSQL": "postgres",
+	"MySQl":      "mysql",
+	"SQLite":     "sqlite3",
+	"SQLServer":  "mssql",
+	"Clickhouse": "clickhouse",
 }
 
 var PrismaOptions []string = []string{
@@ -26,3 +92,27 @@ var GormOptions []string = []string{
 	"SQLServer",
 	"Clickhouse",
 }
+
+var EntOptions []string = []string{
+	"PostgreSQL",
+	"MySQl",
+	"SQLite",
+}
+
+var BunOptions []string = []string{
+	"PostgreSQL",
+	"MySQl",
+	"SQLite",
+	"SQLServer",
+}
+
+var StdlibOptions []string = []string{
+	"PostgreSQL",
+	"MySQl",
+	"SQLite",
+	"SQLServer",
+}
+
+var MongoOptions []string = []string{
+	"MongoDB",
+}
@@ -0,0 +1,179 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/apikey"
+	"github.com/struckchure/go-alchemy/ent/apikey"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	Id     string `json:"id"`
+	UserId string `json:"userId"`
+	Name   string `json:"name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	Revoked    bool       `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (ApiKey) fromModel(apiKey *ent.ApiKey) *ApiKey {
+	if apiKey == nil {
+		return nil
+	}
+
+	return &ApiKey{
+		Id:         apiKey.ID.String(),
+		UserId:     apiKey.UserID.String(),
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		SecretHash: apiKey.SecretHash,
+		Scope:      apiKey.Scope,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		Revoked:    apiKey.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: apiKey.CreatedAt,
+		UpdatedAt: apiKey.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	client *ent.Client
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := txOrClient(ctx, r.client).ApiKey.Create().
+		SetUserID(userId).
+		SetName(payload.Name).
+		SetPrefix(payload.Prefix).
+		SetSecretHash(payload.SecretHash).
+		SetScope(payload.Scope).
+		SetNillableExpiresAt(payload.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (*ApiKey, error) {
+	apiKeyId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := txOrClient(ctx, r.client).ApiKey.Get(ctx, apiKeyId)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	apiKey, err := txOrClient(ctx, r.client).ApiKey.Query().Where(apikey.Prefix(prefix)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, id string) ([]ApiKey, error) {
+	userId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := txOrClient(ctx, r.client).ApiKey.Query().
+		Where(apikey.UserID(userId)).
+		Order(ent.Asc(apikey.FieldName)).
+		All(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	apiKeys := make([]ApiKey, 0, len(models))
+	for _, model := range models {
+		apiKeys = append(apiKeys, *ApiKey{}.fromModel(model))
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	apiKeyId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := txOrClient(ctx, r.client).ApiKey.Update().
+		Where(apikey.ID(apiKeyId), apikey.Revoked(false)).
+		SetRevoked(true).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if revoked == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	apiKeyId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	return translateError(txOrClient(ctx, r.client).ApiKey.UpdateOneID(apiKeyId).SetLastUsedAt(lastUsedAt).Exec(ctx))
+}
+
+func NewApiKeyDao(client *ent.Client) IApiKeyDao {
+	return &ApiKeyDao{client: client}
+}
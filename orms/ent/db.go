@@ -0,0 +1,28 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"os"
+
+	entsql "entgo.io/ent/dialect/sql"
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	_ "github.com/lib/pq"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	_ "github.com/go-sql-driver/mysql"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	_ "github.com/mattn/go-sqlite3"
+	// @alchemy block {{- end }}
+)
+
+// @alchemy replace const dialectName = {{ if eq .DatabaseProvider "mysql" }}"mysql"{{ else if eq .DatabaseProvider "sqlite" }}"sqlite3"{{ else }}"postgres"{{ end }}
+const dialectName = "postgres"
+
+// NewDriver opens the database of the DATABASE_URL environment variable, the
+// same way ent.Open does. The ent client is created from it with
+// ent.NewClient(ent.Driver(driver)), the ent package only exists once the
+// schemas of a component are generated.
+func NewDriver() (*entsql.Driver, error) {
+	return entsql.Open(dialectName, os.Getenv("DATABASE_URL"))
+}
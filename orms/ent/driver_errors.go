@@ -0,0 +1,40 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"fmt"
+
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"github.com/google/uuid"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// translateError converts the errors of ent to the errors of the dao package
+func translateError(err error) error {
+	switch {
+	case err == nil, IsTranslated(err):
+		return err
+	case ent.IsNotFound(err):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case sqlgraph.IsUniqueConstraintError(err):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case ent.IsConstraintError(err), ent.IsValidationError(err):
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	default:
+		return err
+	}
+}
+
+// parseId parses the uuid of a record, a malformed id is ErrInvalid
+func parseId(id string) (uuid.UUID, error) {
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	return parsedId, nil
+}
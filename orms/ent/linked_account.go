@@ -0,0 +1,126 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/linkedaccount"
+	"github.com/struckchure/go-alchemy/ent/linkedaccount"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	Id             string `json:"id"`
+	UserId         string `json:"userId"`
+	Provider       string `json:"provider"`
+	ProviderUserId string `json:"providerUserId"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (LinkedAccount) fromModel(linkedAccount *ent.LinkedAccount) *LinkedAccount {
+	if linkedAccount == nil {
+		return nil
+	}
+
+	return &LinkedAccount{
+		Id:             linkedAccount.ID.String(),
+		UserId:         linkedAccount.UserID.String(),
+		Provider:       linkedAccount.Provider,
+		ProviderUserId: linkedAccount.ProviderUserID,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: linkedAccount.CreatedAt,
+		UpdatedAt: linkedAccount.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	client *ent.Client
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	linkedAccount, err := txOrClient(ctx, l.client).LinkedAccount.Create().
+		SetUserID(userId).
+		SetProvider(payload.Provider).
+		SetProviderUserID(payload.ProviderUserId).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return LinkedAccount{}.fromModel(linkedAccount), nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error) {
+	linkedAccount, err := txOrClient(ctx, l.client).LinkedAccount.Query().
+		Where(linkedaccount.Provider(provider), linkedaccount.ProviderUserID(providerUserId)).
+		Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return LinkedAccount{}.fromModel(linkedAccount), nil
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, id string) ([]LinkedAccount, error) {
+	userId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := txOrClient(ctx, l.client).LinkedAccount.Query().
+		Where(linkedaccount.UserID(userId)).
+		Order(ent.Asc(linkedaccount.FieldProvider)).
+		All(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	linkedAccounts := []LinkedAccount{}
+	for _, model := range models {
+		linkedAccounts = append(linkedAccounts, *LinkedAccount{}.fromModel(model))
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	linkedAccountId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	return translateError(txOrClient(ctx, l.client).LinkedAccount.DeleteOneID(linkedAccountId).Exec(ctx))
+}
+
+func NewLinkedAccountDao(client *ent.Client) ILinkedAccountDao {
+	return &LinkedAccountDao{client: client}
+}
@@ -0,0 +1,119 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/magiclinktoken"
+	"github.com/struckchure/go-alchemy/ent/magiclinktoken"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	Id        string    `json:"id"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (MagicLinkToken) fromModel(magicLinkToken *ent.MagicLinkToken) *MagicLinkToken {
+	if magicLinkToken == nil {
+		return nil
+	}
+
+	return &MagicLinkToken{
+		Id:        magicLinkToken.ID.String(),
+		Email:     magicLinkToken.Email,
+		TokenHash: magicLinkToken.TokenHash,
+		ExpiresAt: magicLinkToken.ExpiresAt,
+		Used:      magicLinkToken.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: magicLinkToken.CreatedAt,
+		UpdatedAt: magicLinkToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	client *ent.Client
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	magicLinkToken, err := txOrClient(ctx, r.client).MagicLinkToken.Create().
+		SetEmail(payload.Email).
+		SetTokenHash(payload.TokenHash).
+		SetExpiresAt(payload.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return MagicLinkToken{}.fromModel(magicLinkToken), nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*MagicLinkToken, error) {
+	magicLinkToken, err := txOrClient(ctx, r.client).MagicLinkToken.Query().Where(magiclinktoken.TokenHash(tokenHash)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return MagicLinkToken{}.fromModel(magicLinkToken), nil
+}
+
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	magicLinkTokenId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	used, err := txOrClient(ctx, r.client).MagicLinkToken.Update().
+		Where(magiclinktoken.ID(magicLinkTokenId), magiclinktoken.Used(false)).
+		SetUsed(true).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if used == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	_, err := txOrClient(ctx, r.client).MagicLinkToken.Update().
+		Where(magiclinktoken.Email(email)).
+		SetUsed(true).
+		Save(ctx)
+	return translateError(err)
+}
+
+func NewMagicLinkTokenDao(client *ent.Client) IMagicLinkTokenDao {
+	return &MagicLinkTokenDao{client: client}
+}
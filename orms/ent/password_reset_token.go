@@ -0,0 +1,129 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/passwordresettoken"
+	"github.com/struckchure/go-alchemy/ent/passwordresettoken"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (PasswordResetToken) fromModel(passwordResetToken *ent.PasswordResetToken) *PasswordResetToken {
+	if passwordResetToken == nil {
+		return nil
+	}
+
+	return &PasswordResetToken{
+		Id:        passwordResetToken.ID.String(),
+		UserId:    passwordResetToken.UserID.String(),
+		TokenHash: passwordResetToken.TokenHash,
+		ExpiresAt: passwordResetToken.ExpiresAt,
+		Used:      passwordResetToken.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: passwordResetToken.CreatedAt,
+		UpdatedAt: passwordResetToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	client *ent.Client
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordResetToken, err := txOrClient(ctx, r.client).PasswordResetToken.Create().
+		SetUserID(userId).
+		SetTokenHash(payload.TokenHash).
+		SetExpiresAt(payload.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return PasswordResetToken{}.fromModel(passwordResetToken), nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	passwordResetToken, err := txOrClient(ctx, r.client).PasswordResetToken.Query().Where(passwordresettoken.TokenHash(tokenHash)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return PasswordResetToken{}.fromModel(passwordResetToken), nil
+}
+
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	passwordResetTokenId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	used, err := txOrClient(ctx, r.client).PasswordResetToken.Update().
+		Where(passwordresettoken.ID(passwordResetTokenId), passwordresettoken.Used(false)).
+		SetUsed(true).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if used == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = txOrClient(ctx, r.client).PasswordResetToken.Update().
+		Where(passwordresettoken.UserID(userId)).
+		SetUsed(true).
+		Save(ctx)
+	return translateError(err)
+}
+
+func NewPasswordResetTokenDao(client *ent.Client) IPasswordResetTokenDao {
+	return &PasswordResetTokenDao{client: client}
+}
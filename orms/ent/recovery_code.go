@@ -0,0 +1,116 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/recoverycode"
+	"github.com/struckchure/go-alchemy/ent/recoverycode"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	Id       string `json:"id"`
+	UserId   string `json:"userId"`
+	CodeHash string `json:"-"`
+	Used     bool   `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (RecoveryCode) fromModel(recoveryCode *ent.RecoveryCode) *RecoveryCode {
+	if recoveryCode == nil {
+		return nil
+	}
+
+	return &RecoveryCode{
+		Id:       recoveryCode.ID.String(),
+		UserId:   recoveryCode.UserID.String(),
+		CodeHash: recoveryCode.CodeHash,
+		Used:     recoveryCode.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: recoveryCode.CreatedAt,
+		UpdatedAt: recoveryCode.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	client *ent.Client
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCode, err := txOrClient(ctx, r.client).RecoveryCode.Create().
+		SetUserID(userId).
+		SetCodeHash(payload.CodeHash).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RecoveryCode{}.fromModel(recoveryCode), nil
+}
+
+func (r *RecoveryCodeDao) Use(ctx context.Context, id string, codeHash string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	used, err := txOrClient(ctx, r.client).RecoveryCode.Update().
+		Where(recoverycode.UserID(userId), recoverycode.CodeHash(codeHash), recoverycode.Used(false)).
+		SetUsed(true).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if used == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = txOrClient(ctx, r.client).RecoveryCode.Delete().Where(recoverycode.UserID(userId)).Exec(ctx)
+	return translateError(err)
+}
+
+func NewRecoveryCodeDao(client *ent.Client) IRecoveryCodeDao {
+	return &RecoveryCodeDao{client: client}
+}
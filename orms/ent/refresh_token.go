@@ -0,0 +1,127 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/refreshtoken"
+	"github.com/struckchure/go-alchemy/ent/refreshtoken"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	FamilyId  string    `json:"familyId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (RefreshToken) fromModel(refreshToken *ent.RefreshToken) *RefreshToken {
+	if refreshToken == nil {
+		return nil
+	}
+
+	return &RefreshToken{
+		Id:        refreshToken.ID.String(),
+		UserId:    refreshToken.UserID.String(),
+		FamilyId:  refreshToken.FamilyID,
+		TokenHash: refreshToken.TokenHash,
+		ExpiresAt: refreshToken.ExpiresAt,
+		Revoked:   refreshToken.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: refreshToken.CreatedAt,
+		UpdatedAt: refreshToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	client *ent.Client
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := txOrClient(ctx, r.client).RefreshToken.Create().
+		SetUserID(userId).
+		SetFamilyID(payload.FamilyId).
+		SetTokenHash(payload.TokenHash).
+		SetExpiresAt(payload.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RefreshToken{}.fromModel(refreshToken), nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	refreshToken, err := txOrClient(ctx, r.client).RefreshToken.Query().Where(refreshtoken.TokenHash(tokenHash)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RefreshToken{}.fromModel(refreshToken), nil
+}
+
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	refreshTokenId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := txOrClient(ctx, r.client).RefreshToken.Update().
+		Where(refreshtoken.ID(refreshTokenId), refreshtoken.Revoked(false)).
+		SetRevoked(true).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if revoked == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	_, err := txOrClient(ctx, r.client).RefreshToken.Update().
+		Where(refreshtoken.FamilyID(familyId)).
+		SetRevoked(true).
+		Save(ctx)
+	return translateError(err)
+}
+
+func NewRefreshTokenDao(client *ent.Client) IRefreshTokenDao {
+	return &RefreshTokenDao{client: client}
+}
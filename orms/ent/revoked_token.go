@@ -0,0 +1,109 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/revokedtoken"
+	"github.com/struckchure/go-alchemy/ent/revokedtoken"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	Jti       *string   `json:"jti"`
+	RevokedAt time.Time `json:"revokedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (RevokedToken) fromModel(revokedToken *ent.RevokedToken) *RevokedToken {
+	if revokedToken == nil {
+		return nil
+	}
+
+	return &RevokedToken{
+		Id:        revokedToken.ID.String(),
+		UserId:    revokedToken.UserID.String(),
+		Jti:       revokedToken.Jti,
+		RevokedAt: revokedToken.RevokedAt,
+		ExpiresAt: revokedToken.ExpiresAt,
+	}
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	client *ent.Client
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	revokedToken, err := txOrClient(ctx, r.client).RevokedToken.Create().
+		SetUserID(userId).
+		SetNillableJti(payload.Jti).
+		SetRevokedAt(time.Now()).
+		SetExpiresAt(payload.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RevokedToken{}.fromModel(revokedToken), nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	id, err := parseId(userId)
+	if err != nil {
+		return false, err
+	}
+
+	revoked, err := txOrClient(ctx, r.client).RevokedToken.Query().
+		Where(revokedtoken.Or(
+			revokedtoken.Jti(jti),
+			revokedtoken.And(
+				revokedtoken.JtiIsNil(),
+				revokedtoken.UserID(id),
+				revokedtoken.RevokedAtGT(issuedAt),
+			),
+		)).
+		Exist(ctx)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return revoked, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).RevokedToken.Delete().
+		Where(revokedtoken.ExpiresAtLT(time.Now())).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewRevokedTokenDao(client *ent.Client) IRevokedTokenDao {
+	return &RevokedTokenDao{client: client}
+}
@@ -0,0 +1,132 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/session"
+	"github.com/struckchure/go-alchemy/ent/session"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (Session) fromModel(session *ent.Session) *Session {
+	if session == nil {
+		return nil
+	}
+
+	return &Session{
+		Id:         session.ID.String(),
+		UserId:     session.UserID.String(),
+		TokenHash:  session.TokenHash,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: session.CreatedAt,
+		UpdatedAt: session.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	client *ent.Client
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := txOrClient(ctx, r.client).Session.Create().
+		SetUserID(userId).
+		SetTokenHash(payload.TokenHash).
+		SetExpiresAt(payload.ExpiresAt).
+		SetLastSeenAt(payload.LastSeenAt).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return Session{}.fromModel(model), nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	model, err := txOrClient(ctx, r.client).Session.Query().Where(session.TokenHash(tokenHash)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return Session{}.fromModel(model), nil
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	sessionId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	return translateError(txOrClient(ctx, r.client).Session.UpdateOneID(sessionId).SetLastSeenAt(lastSeenAt).Exec(ctx))
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	sessionId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = txOrClient(ctx, r.client).Session.Delete().Where(session.ID(sessionId)).Exec(ctx)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = txOrClient(ctx, r.client).Session.Delete().Where(session.UserID(userId)).Exec(ctx)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).Session.Delete().
+		Where(session.ExpiresAtLT(time.Now())).
+		Exec(ctx)
+	return translateError(err)
+}
+
+func NewSessionDao(client *ent.Client) ISessionDao {
+	return &SessionDao{client: client}
+}
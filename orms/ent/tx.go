@@ -0,0 +1,57 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	"errors"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+)
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. The transaction is rolled back if fn returns an
+	// error, nested calls join the outer transaction.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client *ent.Client
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if ent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := t.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ent.NewTxContext(ctx, tx))
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// txOrClient returns the client of the transaction of ctx, or client if ctx has none
+func txOrClient(ctx context.Context, client *ent.Client) *ent.Client {
+	if tx := ent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+
+	return client
+}
+
+func NewTxManager(client *ent.Client) ITxManager {
+	return &TxManager{client: client}
+}
@@ -0,0 +1,361 @@
+// @alchemy replace package dao
+package ent
+
+import (
+	"context"
+	// @alchemy block {{- if or .Timestamps .SoftDelete .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"entgo.io/ent/dialect/sql"
+
+	// @alchemy statement "{{ .ModuleName }}/ent"
+	"github.com/struckchure/go-alchemy/ent"
+	// @alchemy statement "{{ .ModuleName }}/ent/user"
+	"github.com/struckchure/go-alchemy/ent/user"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type User struct {
+	Id        string  `json:"id"`
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+	Email     string  `json:"email"`
+	Password  string  `json:"-"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-"`
+	MfaEnabled bool    `json:"mfaEnabled"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `json:"deletedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (User) fromModel(user *ent.User) *User {
+	if user == nil {
+		return nil
+	}
+
+	return &User{
+		Id:        user.ID.String(),
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Password:  user.Password,
+		// @alchemy block {{- if .EmailVerification }}
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+		MfaSecret:   user.MfaSecret,
+		MfaEnabled:  user.MfaEnabled,
+		MfaTotpStep: user.MfaTotpStep,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .SoftDelete }}
+		DeletedAt: user.DeletedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        user.FieldID,
+	"firstName": user.FieldFirstName,
+	"lastName":  user.FieldLastName,
+	"email":     user.FieldEmail,
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": user.FieldCreatedAt,
+	"updatedAt": user.FieldUpdatedAt,
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+type IUserDao interface {
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
+	// @alchemy block {{- end }}
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
+	// @alchemy block {{- end }}
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
+	// @alchemy block {{- end }}
+}
+
+type UserDao struct {
+	client *ent.Client
+}
+
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	params = *normalizedParams
+
+	query := txOrClient(ctx, u.client).User.Query().Where(func(s *sql.Selector) {
+		for _, filter := range params.Filters {
+			switch filter.Operator {
+			case FilterEquals:
+				s.Where(sql.EQ(s.C(userFields[filter.Field]), filter.Value))
+			case FilterContains:
+				s.Where(sql.Contains(s.C(userFields[filter.Field]), filter.Value.(string)))
+			}
+		}
+	})
+
+	// @alchemy block {{ if .SoftDelete }}
+	if !params.WithDeleted {
+		query = query.Where(user.DeletedAtIsNil())
+	}
+	// @alchemy block {{- end }}
+
+	total, err := query.Clone().Count(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.Where(func(s *sql.Selector) {
+			conditions := []*sql.Predicate{}
+			for i, sort := range params.Sort {
+				parts := []*sql.Predicate{}
+				for j := 0; j < i; j++ {
+					parts = append(parts, sql.EQ(s.C(userFields[params.Sort[j].Field]), values[j]))
+				}
+
+				if sort.Order == SortDesc {
+					parts = append(parts, sql.LT(s.C(userFields[sort.Field]), values[i]))
+				} else {
+					parts = append(parts, sql.GT(s.C(userFields[sort.Field]), values[i]))
+				}
+
+				conditions = append(conditions, sql.And(parts...))
+			}
+
+			s.Where(sql.Or(conditions...))
+		})
+	} else {
+		query = query.Offset(params.Offset)
+	}
+
+	users, err := query.
+		Order(func(s *sql.Selector) {
+			for _, sort := range params.Sort {
+				if sort.Order == SortDesc {
+					s.OrderBy(sql.Desc(s.C(userFields[sort.Field])))
+				} else {
+					s.OrderBy(sql.Asc(s.C(userFields[sort.Field])))
+				}
+			}
+		}).
+		Limit(params.Limit + 1).
+		All(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	result := make([]User, 0, len(users))
+	for _, user := range users {
+		result = append(result, *User{}.fromModel(user))
+	}
+
+	return NewPage(result, int64(total), params)
+}
+
+func (u *UserDao) Get(ctx context.Context, id string) (*User, error) {
+	userId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy replace user, err := txOrClient(ctx, u.client).User.{{ if .SoftDelete }}Query().Where(user.ID(userId), user.DeletedAtIsNil()).Only(ctx){{ else }}Get(ctx, userId){{ end }}
+	user, err := txOrClient(ctx, u.client).User.Get(ctx, userId)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return User{}.fromModel(user), nil
+}
+
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (*User, error) {
+	// @alchemy replace user, err := txOrClient(ctx, u.client).User.Query().Where(user.Email(email){{ if .SoftDelete }}, user.DeletedAtIsNil(){{ end }}).Only(ctx)
+	user, err := txOrClient(ctx, u.client).User.Query().Where(user.Email(email)).Only(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return User{}.fromModel(user), nil
+}
+
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+type UserCreatePayload struct {
+	FirstName *string
+	LastName  *string
+	Email     string
+	Password  string
+}
+
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
+	user, err := txOrClient(ctx, u.client).User.Create().
+		SetEmail(payload.Email).
+		SetPassword(payload.Password).
+		SetNillableFirstName(payload.FirstName).
+		SetNillableLastName(payload.LastName).
+		Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return User{}.fromModel(user), nil
+}
+
+// @alchemy block {{- end }}
+
+type UserUpdatePayload struct {
+	FirstName *string
+	LastName  *string
+	Email     *string
+	Password  *string
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string
+	MfaEnabled *bool
+	// @alchemy block {{- end }}
+}
+
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	userId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := txOrClient(ctx, u.client).User.UpdateOneID(userId).
+		// @alchemy block {{- if .SoftDelete }}
+		Where(user.DeletedAtIsNil()).
+		// @alchemy block {{- end }}
+		SetNillableFirstName(payload.FirstName).
+		SetNillableLastName(payload.LastName)
+	if payload.Email != nil {
+		query.SetEmail(*payload.Email)
+	}
+	if payload.Password != nil {
+		query.SetPassword(*payload.Password)
+	}
+	// @alchemy block {{- if .EmailVerification }}
+	query.SetNillableEmailVerifiedAt(payload.EmailVerifiedAt)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	query.SetNillableMfaSecret(payload.MfaSecret).SetNillableMfaEnabled(payload.MfaEnabled)
+	// @alchemy block {{- end }}
+
+	user, err := query.Save(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return User{}.fromModel(user), nil
+}
+
+// @alchemy block {{- if .MFA }}
+
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	used, err := txOrClient(ctx, u.client).User.Update().
+		Where(user.ID(userId), user.MfaTotpStepLT(step)).
+		SetMfaTotpStep(step).
+		Save(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if used == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// @alchemy block {{- end }}
+
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy block {{ if .SoftDelete }}
+	return translateError(txOrClient(ctx, u.client).User.UpdateOneID(userId).Where(user.DeletedAtIsNil()).SetDeletedAt(time.Now()).Exec(ctx))
+	// @alchemy block {{- else }}
+	return translateError(txOrClient(ctx, u.client).User.DeleteOneID(userId).Exec(ctx))
+	// @alchemy block {{- end }}
+}
+
+// @alchemy block {{- if .SoftDelete }}
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	return translateError(txOrClient(ctx, u.client).User.UpdateOneID(userId).Where(user.DeletedAtNotNil()).ClearDeletedAt().Exec(ctx))
+}
+
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	return translateError(txOrClient(ctx, u.client).User.DeleteOneID(userId).Exec(ctx))
+}
+
+// @alchemy block {{- end }}
+
+func NewUserDao(client *ent.Client) IUserDao {
+	return &UserDao{client: client}
+}
@@ -0,0 +1,136 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId string `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	Name   string `json:"name" gorm:"column:name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix" gorm:"column:prefix;unique"`
+	SecretHash string     `json:"-" gorm:"column:secret_hash"`
+	Scope      string     `json:"scope" gorm:"column:scope"`
+	ExpiresAt  *time.Time `json:"expiresAt" gorm:"column:expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at"`
+	Revoked    bool       `json:"revoked" gorm:"column:revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *ApiKey) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	client *gorm.DB
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	apiKey := ApiKey{
+		UserId:     payload.UserId,
+		Name:       payload.Name,
+		Prefix:     payload.Prefix,
+		SecretHash: payload.SecretHash,
+		Scope:      payload.Scope,
+		ExpiresAt:  payload.ExpiresAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&apiKey).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &apiKey, nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (apiKey *ApiKey, err error) {
+	err = txOrClient(ctx, r.client).Model(&ApiKey{}).Where("id = ?", id).First(&apiKey).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (apiKey *ApiKey, err error) {
+	err = txOrClient(ctx, r.client).Model(&ApiKey{}).Where("prefix = ?", prefix).First(&apiKey).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	apiKeys := []ApiKey{}
+	err := txOrClient(ctx, r.client).Model(&ApiKey{}).Where("user_id = ?", userId).Order("name").Find(&apiKeys).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	result := txOrClient(ctx, r.client).
+		Model(&ApiKey{}).
+		Where("id = ? AND revoked = ?", id, false).
+		Update("revoked", true)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	return translateError(txOrClient(ctx, r.client).Model(&ApiKey{}).Where("id = ?", id).Update("last_used_at", lastUsedAt).Error)
+}
+
+func NewApiKeyDao(client *gorm.DB) IApiKeyDao {
+	return &ApiKeyDao{client: client}
+}
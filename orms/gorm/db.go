@@ -0,0 +1,85 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	"gorm.io/driver/postgres"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	"gorm.io/driver/mysql"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	"gorm.io/driver/sqlite"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	"gorm.io/driver/sqlserver"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "clickhouse" }}
+	"gorm.io/driver/clickhouse"
+	// @alchemy block {{- end }}
+)
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// NewDB opens a gorm database from the DATABASE_URL environment variable.
+//
+// The connection pool is configured with DATABASE_MAX_OPEN_CONNS,
+// DATABASE_MAX_IDLE_CONNS and DATABASE_CONN_MAX_LIFETIME.
+func NewDB() (*gorm.DB, error) {
+	dsn := os.Getenv("DATABASE_URL")
+
+	var dialector gorm.Dialector
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	dialector = postgres.Open(dsn)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	dialector = mysql.Open(dsn)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	dialector = sqlite.Open(dsn)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	dialector = sqlserver.Open(dsn)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "clickhouse" }}
+	dialector = clickhouse.Open(dsn)
+	// @alchemy block {{- end }}
+
+	// TranslateError converts the constraint errors of every provider to gorm errors
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(getEnvInt("DATABASE_MAX_OPEN_CONNS", 25))
+	sqlDB.SetMaxIdleConns(getEnvInt("DATABASE_MAX_IDLE_CONNS", 25))
+	sqlDB.SetConnMaxLifetime(getEnvDuration("DATABASE_CONN_MAX_LIFETIME", 5*time.Minute))
+
+	return db, nil
+}
@@ -0,0 +1,33 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// translateError converts the errors of gorm to the errors of the dao package,
+// the constraint errors are translated by the dialector because NewDB sets
+// TranslateError.
+func translateError(err error) error {
+	switch {
+	case err == nil, IsTranslated(err):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated),
+		errors.Is(err, gorm.ErrCheckConstraintViolated),
+		errors.Is(err, gorm.ErrInvalidField),
+		errors.Is(err, gorm.ErrInvalidData):
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,98 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId         string `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	Provider       string `json:"provider" gorm:"column:provider;uniqueIndex:linked_accounts_provider_user_idx"`
+	ProviderUserId string `json:"providerUserId" gorm:"column:provider_user_id;uniqueIndex:linked_accounts_provider_user_idx"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (l *LinkedAccount) BeforeCreate(*gorm.DB) error {
+	if l.Id == "" {
+		l.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	client *gorm.DB
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	linkedAccount := LinkedAccount{
+		UserId:         payload.UserId,
+		Provider:       payload.Provider,
+		ProviderUserId: payload.ProviderUserId,
+	}
+
+	err := txOrClient(ctx, l.client).Create(&linkedAccount).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &linkedAccount, nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (linkedAccount *LinkedAccount, err error) {
+	err = txOrClient(ctx, l.client).
+		Model(&LinkedAccount{}).
+		Where("provider = ? AND provider_user_id = ?", provider, providerUserId).
+		First(&linkedAccount).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccount, nil
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, userId string) ([]LinkedAccount, error) {
+	linkedAccounts := []LinkedAccount{}
+	err := txOrClient(ctx, l.client).Model(&LinkedAccount{}).Where("user_id = ?", userId).Order("provider").Find(&linkedAccounts).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	return translateError(txOrClient(ctx, l.client).Where("id = ?", id).Delete(&LinkedAccount{}).Error)
+}
+
+func NewLinkedAccountDao(client *gorm.DB) ILinkedAccountDao {
+	return &LinkedAccountDao{client: client}
+}
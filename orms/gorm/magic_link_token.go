@@ -0,0 +1,102 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id        string    `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	Email     string    `json:"email" gorm:"column:email;index;type:varchar(255)"`
+	TokenHash string    `json:"-" gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"column:expires_at"`
+	Used      bool      `json:"used" gorm:"column:used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *MagicLinkToken) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	client *gorm.DB
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	magicLinkToken := MagicLinkToken{
+		Email:     payload.Email,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&magicLinkToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &magicLinkToken, nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (magicLinkToken *MagicLinkToken, err error) {
+	err = txOrClient(ctx, r.client).Model(&MagicLinkToken{}).Where("token_hash = ?", tokenHash).First(&magicLinkToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return magicLinkToken, nil
+}
+
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	result := txOrClient(ctx, r.client).
+		Model(&MagicLinkToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	return translateError(txOrClient(ctx, r.client).Model(&MagicLinkToken{}).Where("email = ?", email).Update("used", true).Error)
+}
+
+func NewMagicLinkTokenDao(client *gorm.DB) IMagicLinkTokenDao {
+	return &MagicLinkTokenDao{client: client}
+}
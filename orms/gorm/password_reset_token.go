@@ -0,0 +1,103 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId    string    `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	TokenHash string    `json:"-" gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"column:expires_at"`
+	Used      bool      `json:"used" gorm:"column:used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *PasswordResetToken) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	client *gorm.DB
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	passwordResetToken := PasswordResetToken{
+		UserId:    payload.UserId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&passwordResetToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &passwordResetToken, nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (passwordResetToken *PasswordResetToken, err error) {
+	err = txOrClient(ctx, r.client).Model(&PasswordResetToken{}).Where("token_hash = ?", tokenHash).First(&passwordResetToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return passwordResetToken, nil
+}
+
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	result := txOrClient(ctx, r.client).
+		Model(&PasswordResetToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, userId string) error {
+	return translateError(txOrClient(ctx, r.client).Model(&PasswordResetToken{}).Where("user_id = ?", userId).Update("used", true).Error)
+}
+
+func NewPasswordResetTokenDao(client *gorm.DB) IPasswordResetTokenDao {
+	return &PasswordResetTokenDao{client: client}
+}
@@ -0,0 +1,94 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId   string `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	CodeHash string `json:"-" gorm:"column:code_hash"`
+	Used     bool   `json:"used" gorm:"column:used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *RecoveryCode) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	client *gorm.DB
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	recoveryCode := RecoveryCode{
+		UserId:   payload.UserId,
+		CodeHash: payload.CodeHash,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&recoveryCode).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &recoveryCode, nil
+}
+
+func (r *RecoveryCodeDao) Use(ctx context.Context, userId string, codeHash string) error {
+	result := txOrClient(ctx, r.client).
+		Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used = ?", userId, codeHash, false).
+		Update("used", true)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	return translateError(txOrClient(ctx, r.client).Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error)
+}
+
+func NewRecoveryCodeDao(client *gorm.DB) IRecoveryCodeDao {
+	return &RecoveryCodeDao{client: client}
+}
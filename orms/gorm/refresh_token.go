@@ -0,0 +1,105 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId    string    `json:"userId" gorm:"column:user_id;type:uuid"`
+	FamilyId  string    `json:"familyId" gorm:"column:family_id;index"`
+	TokenHash string    `json:"-" gorm:"column:token_hash;unique"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"column:expires_at"`
+	Revoked   bool      `json:"revoked" gorm:"column:revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *RefreshToken) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	client *gorm.DB
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	refreshToken := RefreshToken{
+		UserId:    payload.UserId,
+		FamilyId:  payload.FamilyId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&refreshToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (refreshToken *RefreshToken, err error) {
+	err = txOrClient(ctx, r.client).Model(&RefreshToken{}).Where("token_hash = ?", tokenHash).First(&refreshToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return refreshToken, nil
+}
+
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	result := txOrClient(ctx, r.client).
+		Model(&RefreshToken{}).
+		Where("id = ? AND revoked = ?", id, false).
+		Update("revoked", true)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	return translateError(txOrClient(ctx, r.client).Model(&RefreshToken{}).Where("family_id = ?", familyId).Update("revoked", true).Error)
+}
+
+func NewRefreshTokenDao(client *gorm.DB) IRefreshTokenDao {
+	return &RefreshTokenDao{client: client}
+}
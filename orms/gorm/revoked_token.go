@@ -0,0 +1,87 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId    string    `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	Jti       *string   `json:"jti" gorm:"column:jti;index"`
+	RevokedAt time.Time `json:"revokedAt" gorm:"column:revoked_at"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"column:expires_at"`
+}
+
+func (r *RevokedToken) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	client *gorm.DB
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	revokedToken := RevokedToken{
+		UserId:    payload.UserId,
+		Jti:       payload.Jti,
+		RevokedAt: time.Now(),
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&revokedToken).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &revokedToken, nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := txOrClient(ctx, r.client).
+		Model(&RevokedToken{}).
+		Where("jti = ? OR (jti IS NULL AND user_id = ? AND revoked_at > ?)", jti, userId, issuedAt).
+		Count(&count).Error
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	return translateError(txOrClient(ctx, r.client).Where("expires_at < ?", time.Now()).Delete(&RevokedToken{}).Error)
+}
+
+func NewRevokedTokenDao(client *gorm.DB) IRevokedTokenDao {
+	return &RevokedTokenDao{client: client}
+}
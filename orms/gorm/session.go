@@ -0,0 +1,99 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id string `json:"id" gorm:"column:id;primaryKey;type:uuid"`
+	// @alchemy replace UserId string `json:"userId" gorm:"column:user_id;index;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	UserId     string    `json:"userId" gorm:"column:user_id;index;type:uuid"`
+	TokenHash  string    `json:"-" gorm:"column:token_hash;unique"`
+	ExpiresAt  time.Time `json:"expiresAt" gorm:"column:expires_at"`
+	LastSeenAt time.Time `json:"lastSeenAt" gorm:"column:last_seen_at"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+}
+
+func (r *Session) BeforeCreate(*gorm.DB) error {
+	if r.Id == "" {
+		r.Id = uuid.NewString()
+	}
+
+	return nil
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	client *gorm.DB
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	session := Session{
+		UserId:     payload.UserId,
+		TokenHash:  payload.TokenHash,
+		ExpiresAt:  payload.ExpiresAt,
+		LastSeenAt: payload.LastSeenAt,
+	}
+
+	err := txOrClient(ctx, r.client).Create(&session).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &session, nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (session *Session, err error) {
+	err = txOrClient(ctx, r.client).Model(&Session{}).Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return session, nil
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	return translateError(txOrClient(ctx, r.client).Model(&Session{}).Where("id = ?", id).Update("last_seen_at", lastSeenAt).Error)
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	return translateError(txOrClient(ctx, r.client).Where("id = ?", id).Delete(&Session{}).Error)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	return translateError(txOrClient(ctx, r.client).Where("user_id = ?", userId).Delete(&Session{}).Error)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	return translateError(txOrClient(ctx, r.client).Where("expires_at < ?", time.Now()).Delete(&Session{}).Error)
+}
+
+func NewSessionDao(client *gorm.DB) ISessionDao {
+	return &SessionDao{client: client}
+}
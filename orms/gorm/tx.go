@@ -0,0 +1,44 @@
+// @alchemy replace package dao
+package gorm
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. The transaction is rolled back if fn returns an
+	// error, nested calls join the outer transaction.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client *gorm.DB
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return t.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// txOrClient returns the transaction of ctx, or client if ctx has none
+func txOrClient(ctx context.Context, client *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return client.WithContext(ctx)
+}
+
+func NewTxManager(client *gorm.DB) ITxManager {
+	return &TxManager{client: client}
+}
@@ -2,66 +2,170 @@
 package gorm
 
 import (
+	"context"
+	// @alchemy block {{- if or .Timestamps .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
 )
 
 type User struct {
-	Id        string  `json:"id" gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	// @alchemy replace Id string `json:"id" gorm:"column:id;primaryKey;{{ if eq .DatabaseProvider "postgresql" }}type:uuid{{ else }}type:varchar(36){{ end }}"`
+	Id        string  `json:"id" gorm:"column:id;primaryKey;type:uuid"`
 	FirstName *string `json:"firstName" gorm:"column:first_name"`
 	LastName  *string `json:"lastName" gorm:"column:last_name"`
-	Email     string  `json:"email" gorm:"email;unique"`
-	Password  string  `json:"-" gorm:"password"`
+	Email     string  `json:"email" gorm:"column:email;unique"`
+	Password  string  `json:"-" gorm:"column:password"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt" gorm:"column:email_verified_at"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-" gorm:"column:mfa_secret"`
+	MfaEnabled bool    `json:"mfaEnabled" gorm:"column:mfa_enabled;not null;default:false"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-" gorm:"column:mfa_totp_step;not null;default:0"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+	// @alchemy block {{- end }}
+}
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        "id",
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+func (u *User) BeforeCreate(*gorm.DB) error {
+	if u.Id == "" {
+		u.Id = uuid.NewString()
+	}
+
+	return nil
 }
 
 type IUserDao interface {
-	List() ([]User, error)
-	// @alchemy block {{- if .Login }}
-	Get(string) (*User, error)
-	GetByEmail(string) (*User, error)
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
+	// @alchemy block {{- end }}
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
 	// @alchemy block {{- end }}
-	// @alchemy block {{- if .Register }}
-	Create(UserCreatePayload) (*User, error)
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
 	// @alchemy block {{- end }}
-	Update(string, UserUpdatePayload) (*User, error)
-	Delete(string) error
 }
 
 type UserDao struct {
 	client *gorm.DB
 }
 
-func (u *UserDao) List() (users []User, err error) {
-	err = u.client.Model(&User{}).Find(&users).Error
+func (u *UserDao) filtered(ctx context.Context, params ListParams) *gorm.DB {
+	query := txOrClient(ctx, u.client).Model(&User{})
+	// @alchemy block {{- if .SoftDelete }}
+	if params.WithDeleted {
+		query = query.Unscoped()
+	}
+	// @alchemy block {{- end }}
+
+	condition, args := SqlFilters(params.Filters, userFields)
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+
+	return query
+}
+
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
 	if err != nil {
 		return nil, err
 	}
 
-	return users, err
+	params = *normalizedParams
+
+	var total int64
+	err = u.filtered(ctx, params).Count(&total).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	query := u.filtered(ctx, params)
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		condition, args := SqlKeyset(params.Sort, values, userFields)
+		query = query.Where(condition, args...)
+	} else {
+		query = query.Offset(params.Offset)
+	}
+
+	var users []User
+	err = query.Order(SqlOrderBy(params.Sort, userFields)).Limit(params.Limit + 1).Find(&users).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return NewPage(users, total, params)
 }
 
-func (u *UserDao) Get(id string) (user *User, err error) {
-	err = u.client.Model(&User{}).Where("id = ?", id).First(&user).Error
+func (u *UserDao) Get(ctx context.Context, id string) (user *User, err error) {
+	err = txOrClient(ctx, u.client).Model(&User{}).Where("id = ?", id).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return user, err
 }
 
-// @alchemy block {{- if .Login }}
-func (u *UserDao) GetByEmail(email string) (user *User, err error) {
-	err = u.client.Model(&User{}).Where("email = ?", email).First(&user).Error
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (user *User, err error) {
+	err = txOrClient(ctx, u.client).Model(&User{}).Where("email = ?", email).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return user, err
 }
 
-// @alchemy block {{- end }}
-
-// @alchemy block {{- if .Register }}
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
 
 type UserCreatePayload struct {
 	FirstName *string `json:"firstName,omitempty"`
@@ -70,17 +174,17 @@ type UserCreatePayload struct {
 	Password  string  `json:"password,omitempty"`
 }
 
-func (u *UserDao) Create(payload UserCreatePayload) (*User, error) {
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
 	user := User{}
 
-	SetIfPresent(user, "FirstName", payload.FirstName)
-	SetIfPresent(user, "LastName", payload.LastName)
+	SetIfPresent(&user, "FirstName", payload.FirstName)
+	SetIfPresent(&user, "LastName", payload.LastName)
 	user.Email = payload.Email
 	user.Password = payload.Password
 
-	err := u.client.Create(&user).Error
+	err := txOrClient(ctx, u.client).Create(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &user, err
@@ -93,30 +197,68 @@ type UserUpdatePayload struct {
 	LastName  *string `json:"lastName,omitempty"`
 	Email     *string `json:"email,omitempty"`
 	Password  *string `json:"password,omitempty"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"mfaSecret,omitempty"`
+	MfaEnabled *bool   `json:"mfaEnabled,omitempty"`
+	// @alchemy block {{- end }}
 }
 
-func (u *UserDao) Update(id string, payload UserUpdatePayload) (*User, error) {
-	user := User{}
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	result := txOrClient(ctx, u.client).Model(&User{}).Where("id = ?", id).Updates(payload)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
 
-	SetIfPresent(user, "FirstName", payload.FirstName)
-	SetIfPresent(user, "LastName", payload.LastName)
-	SetIfPresent(user, "Email", payload.Email)
-	SetIfPresent(user, "Password", payload.Password)
+	// @alchemy block {{- if ne .DatabaseProvider "clickhouse" }}
+	// clickhouse doesn't report the rows of updates, Get fails for a missing
+	// user there
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
 
-	err := u.client.
-		Clauses(clause.Returning{}).
-		Model(&user).Where("id = ?", id).Updates(payload).Error
-	if err != nil {
-		return nil, err
+	// @alchemy block {{- end }}
+	// the updated user is read back, as mysql has no RETURNING
+	return u.Get(ctx, id)
+}
+
+// @alchemy block {{- if .MFA }}
+
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	result := txOrClient(ctx, u.client).
+		Model(&User{}).
+		Where("id = ? AND mfa_totp_step < ?", id, step).
+		Update("mfa_totp_step", step)
+	if result.Error != nil {
+		return translateError(result.Error)
 	}
 
-	return &user, err
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
-func (u *UserDao) Delete(id string) error {
-	return u.client.Where("id = ?", id).Delete(&User{}).Error
+// @alchemy block {{- end }}
+
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	return translateError(txOrClient(ctx, u.client).Where("id = ?", id).Delete(&User{}).Error)
+}
+
+// @alchemy block {{- if .SoftDelete }}
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	return translateError(txOrClient(ctx, u.client).Unscoped().Model(&User{}).Where("id = ?", id).Update("deleted_at", nil).Error)
 }
 
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	return translateError(txOrClient(ctx, u.client).Unscoped().Where("id = ?", id).Delete(&User{}).Error)
+}
+
+// @alchemy block {{- end }}
+
 func NewUserDao(client *gorm.DB) IUserDao {
 	return &UserDao{client: client}
 }
@@ -0,0 +1,220 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	Id     string `json:"id"`
+	UserId string `json:"userId"`
+	Name   string `json:"name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	Revoked    bool       `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type apiKeyDocument struct {
+	Id         bson.ObjectID `bson:"_id,omitempty"`
+	UserId     bson.ObjectID `bson:"userId"`
+	Name       string        `bson:"name"`
+	Prefix     string        `bson:"prefix"`
+	SecretHash string        `bson:"secretHash"`
+	Scope      string        `bson:"scope"`
+	ExpiresAt  *time.Time    `bson:"expiresAt,omitempty"`
+	LastUsedAt *time.Time    `bson:"lastUsedAt,omitempty"`
+	Revoked    bool          `bson:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d apiKeyDocument) toApiKey() *ApiKey {
+	return &ApiKey{
+		Id:         d.Id.Hex(),
+		UserId:     d.UserId.Hex(),
+		Name:       d.Name,
+		Prefix:     d.Prefix,
+		SecretHash: d.SecretHash,
+		Scope:      d.Scope,
+		ExpiresAt:  d.ExpiresAt,
+		LastUsedAt: d.LastUsedAt,
+		Revoked:    d.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	collection *mongo.Collection
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := apiKeyDocument{
+		Id:         bson.NewObjectID(),
+		UserId:     userId,
+		Name:       payload.Name,
+		Prefix:     payload.Prefix,
+		SecretHash: payload.SecretHash,
+		Scope:      payload.Scope,
+		ExpiresAt:  payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toApiKey(), nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (*ApiKey, error) {
+	objectId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	document := apiKeyDocument{}
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toApiKey(), nil
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	document := apiKeyDocument{}
+	err := r.collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toApiKey(), nil
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	objectId, err := parseId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": objectId}, options.Find().SetSort(bson.D{bson.E{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	documents := []apiKeyDocument{}
+	err = cursor.All(ctx, &documents)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	apiKeys := make([]ApiKey, 0, len(documents))
+	for _, document := range documents {
+		apiKeys = append(apiKeys, *document.toApiKey())
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "revoked": false}, bson.M{"$set": bson.M{"revoked": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "revoked": false}, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.M{"$set": bson.M{"lastUsedAt": lastUsedAt}})
+	return translateError(err)
+}
+
+// NewApiKeyDao uses the `api_keys` collection of database and makes sure its
+// prefix and user indexes exist.
+func NewApiKeyDao(database *mongo.Database) (IApiKeyDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("api_keys")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "prefix", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApiKeyDao{collection: collection}, nil
+}
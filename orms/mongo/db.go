@@ -0,0 +1,32 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"errors"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+)
+
+// NewDB connects to the database in the DATABASE_URL environment variable
+func NewDB() (*mongo.Database, error) {
+	uri := os.Getenv("DATABASE_URL")
+
+	connString, err := connstring.ParseAndValidate(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if connString.Database == "" {
+		return nil, errors.New("DATABASE_URL must include a database name")
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Database(connString.Database), nil
+}
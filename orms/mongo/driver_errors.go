@@ -0,0 +1,45 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// documentValidationFailure is the code of the writes rejected by a schema validator
+const documentValidationFailure int = 121
+
+// translateError converts the errors of the mongodb driver to the errors of the
+// dao package
+func translateError(err error) error {
+	var serverErr mongo.ServerError
+
+	switch {
+	case err == nil, IsTranslated(err):
+		return err
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.As(err, &serverErr) && serverErr.HasErrorCode(documentValidationFailure):
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	default:
+		return err
+	}
+}
+
+// parseId parses the ObjectID of a document, a malformed id is ErrInvalid
+func parseId(id string) (bson.ObjectID, error) {
+	objectId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return bson.NilObjectID, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	return objectId, nil
+}
@@ -0,0 +1,160 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	Id             string `json:"id"`
+	UserId         string `json:"userId"`
+	Provider       string `json:"provider"`
+	ProviderUserId string `json:"providerUserId"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type linkedAccountDocument struct {
+	Id             bson.ObjectID `bson:"_id,omitempty"`
+	UserId         bson.ObjectID `bson:"userId"`
+	Provider       string        `bson:"provider"`
+	ProviderUserId string        `bson:"providerUserId"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d linkedAccountDocument) toLinkedAccount() *LinkedAccount {
+	return &LinkedAccount{
+		Id:             d.Id.Hex(),
+		UserId:         d.UserId.Hex(),
+		Provider:       d.Provider,
+		ProviderUserId: d.ProviderUserId,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	collection *mongo.Collection
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := linkedAccountDocument{
+		Id:             bson.NewObjectID(),
+		UserId:         userId,
+		Provider:       payload.Provider,
+		ProviderUserId: payload.ProviderUserId,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = l.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toLinkedAccount(), nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error) {
+	document := linkedAccountDocument{}
+	err := l.collection.FindOne(ctx, bson.M{"provider": provider, "providerUserId": providerUserId}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toLinkedAccount(), nil
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, id string) ([]LinkedAccount, error) {
+	userId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := l.collection.Find(ctx, bson.M{"userId": userId}, options.Find().SetSort(bson.D{bson.E{Key: "provider", Value: 1}}))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	documents := []linkedAccountDocument{}
+	err = cursor.All(ctx, &documents)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	linkedAccounts := []LinkedAccount{}
+	for _, document := range documents {
+		linkedAccounts = append(linkedAccounts, *document.toLinkedAccount())
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.collection.DeleteOne(ctx, bson.M{"_id": objectId})
+	return translateError(err)
+}
+
+// NewLinkedAccountDao uses the `linked_accounts` collection of database and
+// makes sure its provider user and user indexes exist.
+func NewLinkedAccountDao(database *mongo.Database) (ILinkedAccountDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("linked_accounts")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "provider", Value: 1}, bson.E{Key: "providerUserId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinkedAccountDao{collection: collection}, nil
+}
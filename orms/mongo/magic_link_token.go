@@ -0,0 +1,149 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	Id        string    `json:"id"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type magicLinkTokenDocument struct {
+	Id        bson.ObjectID `bson:"_id,omitempty"`
+	Email     string        `bson:"email"`
+	TokenHash string        `bson:"tokenHash"`
+	ExpiresAt time.Time     `bson:"expiresAt"`
+	Used      bool          `bson:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d magicLinkTokenDocument) toMagicLinkToken() *MagicLinkToken {
+	return &MagicLinkToken{
+		Id:        d.Id.Hex(),
+		Email:     d.Email,
+		TokenHash: d.TokenHash,
+		ExpiresAt: d.ExpiresAt,
+		Used:      d.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	collection *mongo.Collection
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	document := magicLinkTokenDocument{
+		Id:        bson.NewObjectID(),
+		Email:     payload.Email,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toMagicLinkToken(), nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*MagicLinkToken, error) {
+	document := magicLinkTokenDocument{}
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toMagicLinkToken(), nil
+}
+
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "used": false}, bson.M{"$set": bson.M{"used": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "used": false}, bson.M{"$set": bson.M{"used": true}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	// @alchemy replace _, err := r.collection.UpdateMany(ctx, bson.M{"email": email}, bson.M{"$set": bson.M{"used": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	_, err := r.collection.UpdateMany(ctx, bson.M{"email": email}, bson.M{"$set": bson.M{"used": true}})
+	return translateError(err)
+}
+
+// NewMagicLinkTokenDao uses the `magic_link_tokens` collection of
+// database and makes sure its token hash and email indexes exist.
+func NewMagicLinkTokenDao(database *mongo.Database) (IMagicLinkTokenDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("magic_link_tokens")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "email", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MagicLinkTokenDao{collection: collection}, nil
+}
@@ -0,0 +1,159 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type passwordResetTokenDocument struct {
+	Id        bson.ObjectID `bson:"_id,omitempty"`
+	UserId    bson.ObjectID `bson:"userId"`
+	TokenHash string        `bson:"tokenHash"`
+	ExpiresAt time.Time     `bson:"expiresAt"`
+	Used      bool          `bson:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d passwordResetTokenDocument) toPasswordResetToken() *PasswordResetToken {
+	return &PasswordResetToken{
+		Id:        d.Id.Hex(),
+		UserId:    d.UserId.Hex(),
+		TokenHash: d.TokenHash,
+		ExpiresAt: d.ExpiresAt,
+		Used:      d.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	collection *mongo.Collection
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := passwordResetTokenDocument{
+		Id:        bson.NewObjectID(),
+		UserId:    userId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toPasswordResetToken(), nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	document := passwordResetTokenDocument{}
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toPasswordResetToken(), nil
+}
+
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "used": false}, bson.M{"$set": bson.M{"used": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "used": false}, bson.M{"$set": bson.M{"used": true}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace _, err = r.collection.UpdateMany(ctx, bson.M{"userId": userId}, bson.M{"$set": bson.M{"used": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	_, err = r.collection.UpdateMany(ctx, bson.M{"userId": userId}, bson.M{"$set": bson.M{"used": true}})
+	return translateError(err)
+}
+
+// NewPasswordResetTokenDao uses the `password_reset_tokens` collection of
+// database and makes sure its token hash and user indexes exist.
+func NewPasswordResetTokenDao(database *mongo.Database) (IPasswordResetTokenDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("password_reset_tokens")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordResetTokenDao{collection: collection}, nil
+}
@@ -0,0 +1,137 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	Id       string `json:"id"`
+	UserId   string `json:"userId"`
+	CodeHash string `json:"-"`
+	Used     bool   `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type recoveryCodeDocument struct {
+	Id       bson.ObjectID `bson:"_id,omitempty"`
+	UserId   bson.ObjectID `bson:"userId"`
+	CodeHash string        `bson:"codeHash"`
+	Used     bool          `bson:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d recoveryCodeDocument) toRecoveryCode() *RecoveryCode {
+	return &RecoveryCode{
+		Id:       d.Id.Hex(),
+		UserId:   d.UserId.Hex(),
+		CodeHash: d.CodeHash,
+		Used:     d.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	collection *mongo.Collection
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := recoveryCodeDocument{
+		Id:       bson.NewObjectID(),
+		UserId:   userId,
+		CodeHash: payload.CodeHash,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toRecoveryCode(), nil
+}
+
+func (r *RecoveryCodeDao) Use(ctx context.Context, id string, codeHash string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace result, err := r.collection.UpdateOne(ctx, bson.M{"userId": userId, "codeHash": codeHash, "used": false}, bson.M{"$set": bson.M{"used": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"userId": userId, "codeHash": codeHash, "used": false}, bson.M{"$set": bson.M{"used": true}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteMany(ctx, bson.M{"userId": userId})
+	return translateError(err)
+}
+
+// NewRecoveryCodeDao uses the `recovery_codes` collection of database and
+// makes sure its user index exists.
+func NewRecoveryCodeDao(database *mongo.Database) (IRecoveryCodeDao, error) {
+	collection := database.Collection("recovery_codes")
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodeDao{collection: collection}, nil
+}
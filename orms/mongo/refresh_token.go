@@ -0,0 +1,158 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	FamilyId  string    `json:"familyId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type refreshTokenDocument struct {
+	Id        bson.ObjectID `bson:"_id,omitempty"`
+	UserId    bson.ObjectID `bson:"userId"`
+	FamilyId  string        `bson:"familyId"`
+	TokenHash string        `bson:"tokenHash"`
+	ExpiresAt time.Time     `bson:"expiresAt"`
+	Revoked   bool          `bson:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d refreshTokenDocument) toRefreshToken() *RefreshToken {
+	return &RefreshToken{
+		Id:        d.Id.Hex(),
+		UserId:    d.UserId.Hex(),
+		FamilyId:  d.FamilyId,
+		TokenHash: d.TokenHash,
+		ExpiresAt: d.ExpiresAt,
+		Revoked:   d.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	collection *mongo.Collection
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := refreshTokenDocument{
+		Id:        bson.NewObjectID(),
+		UserId:    userId,
+		FamilyId:  payload.FamilyId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toRefreshToken(), nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	document := refreshTokenDocument{}
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toRefreshToken(), nil
+}
+
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "revoked": false}, bson.M{"$set": bson.M{"revoked": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId, "revoked": false}, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	// @alchemy replace _, err := r.collection.UpdateMany(ctx, bson.M{"familyId": familyId}, bson.M{"$set": bson.M{"revoked": true{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	_, err := r.collection.UpdateMany(ctx, bson.M{"familyId": familyId}, bson.M{"$set": bson.M{"revoked": true}})
+	return translateError(err)
+}
+
+// NewRefreshTokenDao uses the `refresh_tokens` collection of database and makes
+// sure its token hash and family indexes exist.
+func NewRefreshTokenDao(database *mongo.Database) (IRefreshTokenDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("refresh_tokens")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "familyId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshTokenDao{collection: collection}, nil
+}
@@ -0,0 +1,113 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	Jti       *string   `json:"jti"`
+	RevokedAt time.Time `json:"revokedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type revokedTokenDocument struct {
+	Id        bson.ObjectID `bson:"_id,omitempty"`
+	UserId    string        `bson:"userId"`
+	Jti       *string       `bson:"jti"`
+	RevokedAt time.Time     `bson:"revokedAt"`
+	ExpiresAt time.Time     `bson:"expiresAt"`
+}
+
+func (d revokedTokenDocument) toRevokedToken() *RevokedToken {
+	return &RevokedToken{
+		Id:        d.Id.Hex(),
+		UserId:    d.UserId,
+		Jti:       d.Jti,
+		RevokedAt: d.RevokedAt,
+		ExpiresAt: d.ExpiresAt,
+	}
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	collection *mongo.Collection
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	document := revokedTokenDocument{
+		Id:        bson.NewObjectID(),
+		UserId:    payload.UserId,
+		Jti:       payload.Jti,
+		RevokedAt: time.Now(),
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	_, err := r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toRevokedToken(), nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"jti": jti},
+		bson.M{"jti": nil, "userId": userId, "revokedAt": bson.M{"$gt": issuedAt}},
+	}})
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lt": time.Now()}})
+	return translateError(err)
+}
+
+// NewRevokedTokenDao uses the `revoked_tokens` collection of database and makes
+// sure its jti and user indexes exist.
+func NewRevokedTokenDao(database *mongo.Database) (IRevokedTokenDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("revoked_tokens")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{bson.E{Key: "jti", Value: 1}},
+		},
+		{
+			Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevokedTokenDao{collection: collection}, nil
+}
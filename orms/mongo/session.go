@@ -0,0 +1,164 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+type sessionDocument struct {
+	Id         bson.ObjectID `bson:"_id,omitempty"`
+	UserId     bson.ObjectID `bson:"userId"`
+	TokenHash  string        `bson:"tokenHash"`
+	ExpiresAt  time.Time     `bson:"expiresAt"`
+	LastSeenAt time.Time     `bson:"lastSeenAt"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (d sessionDocument) toSession() *Session {
+	return &Session{
+		Id:         d.Id.Hex(),
+		UserId:     d.UserId.Hex(),
+		TokenHash:  d.TokenHash,
+		ExpiresAt:  d.ExpiresAt,
+		LastSeenAt: d.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	collection *mongo.Collection
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	userId, err := parseId(payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	document := sessionDocument{
+		Id:         bson.NewObjectID(),
+		UserId:     userId,
+		TokenHash:  payload.TokenHash,
+		ExpiresAt:  payload.ExpiresAt,
+		LastSeenAt: payload.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err = r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toSession(), nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	document := sessionDocument{}
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toSession(), nil
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.M{"$set": bson.M{"lastSeenAt": lastSeenAt}})
+	return translateError(err)
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectId})
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, id string) error {
+	userId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteMany(ctx, bson.M{"userId": userId})
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lt": time.Now()}})
+	return translateError(err)
+}
+
+// NewSessionDao uses the `sessions` collection of database and makes sure its
+// token hash and user indexes exist.
+func NewSessionDao(database *mongo.Database) (ISessionDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("sessions")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "userId", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionDao{collection: collection}, nil
+}
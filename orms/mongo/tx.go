@@ -0,0 +1,45 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. The transaction is aborted if fn returns an
+	// error, nested calls join the outer transaction.
+	//
+	// MongoDB only supports transactions on replica sets and sharded clusters.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client *mongo.Client
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// the driver uses the session of the context passed to fn, so the DAOs
+	// don't need to know about the transaction
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
+
+func NewTxManager(database *mongo.Database) ITxManager {
+	return &TxManager{client: database.Client()}
+}
@@ -0,0 +1,469 @@
+// @alchemy replace package dao
+package mongo
+
+import (
+	"context"
+	"regexp"
+	// @alchemy block {{- if or .Timestamps .SoftDelete .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type User struct {
+	Id        string  `json:"id"`
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+	Email     string  `json:"email"`
+	Password  string  `json:"-"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-"`
+	MfaEnabled bool    `json:"mfaEnabled"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `json:"deletedAt"`
+	// @alchemy block {{- end }}
+}
+
+type userDocument struct {
+	Id        bson.ObjectID `bson:"_id,omitempty"`
+	FirstName *string       `bson:"firstName,omitempty"`
+	LastName  *string       `bson:"lastName,omitempty"`
+	Email     string        `bson:"email"`
+	Password  string        `bson:"password"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret   *string `bson:"mfaSecret,omitempty"`
+	MfaEnabled  bool    `bson:"mfaEnabled"`
+	MfaTotpStep int64   `bson:"mfaTotpStep"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `bson:"deletedAt,omitempty"`
+	// @alchemy block {{- end }}
+}
+
+func (d userDocument) toUser() *User {
+	return &User{
+		Id:        d.Id.Hex(),
+		FirstName: d.FirstName,
+		LastName:  d.LastName,
+		Email:     d.Email,
+		Password:  d.Password,
+		// @alchemy block {{- if .EmailVerification }}
+		EmailVerifiedAt: d.EmailVerifiedAt,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+		MfaSecret:   d.MfaSecret,
+		MfaEnabled:  d.MfaEnabled,
+		MfaTotpStep: d.MfaTotpStep,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .SoftDelete }}
+		DeletedAt: d.DeletedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        "_id",
+	"firstName": "firstName",
+	"lastName":  "lastName",
+	"email":     "email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": "createdAt",
+	"updatedAt": "updatedAt",
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+// userValue converts the value of a filter or cursor, which is decoded into
+// the type of its User field, to the type stored in mongodb
+func userValue(field string, value any) (any, error) {
+	id, ok := value.(string)
+	if field != "id" || !ok {
+		return value, nil
+	}
+
+	return parseId(id)
+}
+
+type IUserDao interface {
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
+	// @alchemy block {{- end }}
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
+	// @alchemy block {{- end }}
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
+	// @alchemy block {{- end }}
+}
+
+type UserDao struct {
+	collection *mongo.Collection
+}
+
+func (u *UserDao) findOne(ctx context.Context, filter bson.M) (*User, error) {
+	// @alchemy block {{- if .SoftDelete }}
+	filter["deletedAt"] = nil
+
+	// @alchemy block {{ end }}
+	document := userDocument{}
+	err := u.collection.FindOne(ctx, filter).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toUser(), nil
+}
+
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	params = *normalizedParams
+
+	conditions := bson.A{}
+	// @alchemy block {{- if .SoftDelete }}
+	if !params.WithDeleted {
+		conditions = append(conditions, bson.M{"deletedAt": nil})
+	}
+	// @alchemy block {{- end }}
+
+	for _, filter := range params.Filters {
+		switch filter.Operator {
+		case FilterEquals:
+			value, err := userValue(filter.Field, filter.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			conditions = append(conditions, bson.M{userFields[filter.Field]: value})
+		case FilterContains:
+			pattern := regexp.QuoteMeta(filter.Value.(string))
+			conditions = append(conditions, bson.M{userFields[filter.Field]: bson.M{"$regex": pattern}})
+		}
+	}
+
+	filter := bson.M{}
+	if len(conditions) > 0 {
+		filter = bson.M{"$and": conditions}
+	}
+
+	total, err := u.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	findOptions := options.Find().SetLimit(int64(params.Limit + 1))
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		keyset := bson.A{}
+		for i := range params.Sort {
+			parts := bson.A{}
+			for j := 0; j <= i; j++ {
+				value, err := userValue(params.Sort[j].Field, values[j])
+				if err != nil {
+					return nil, err
+				}
+
+				operator := "$eq"
+				if j == i {
+					operator = "$gt"
+					if params.Sort[j].Order == SortDesc {
+						operator = "$lt"
+					}
+				}
+
+				parts = append(parts, bson.M{userFields[params.Sort[j].Field]: bson.M{operator: value}})
+			}
+
+			keyset = append(keyset, bson.M{"$and": parts})
+		}
+
+		filter = bson.M{"$and": append(conditions, bson.M{"$or": keyset})}
+	} else {
+		findOptions.SetSkip(int64(params.Offset))
+	}
+
+	sort := bson.D{}
+	for _, s := range params.Sort {
+		direction := 1
+		if s.Order == SortDesc {
+			direction = -1
+		}
+
+		sort = append(sort, bson.E{Key: userFields[s.Field], Value: direction})
+	}
+
+	cursor, err := u.collection.Find(ctx, filter, findOptions.SetSort(sort))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	documents := []userDocument{}
+	err = cursor.All(ctx, &documents)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	users := make([]User, 0, len(documents))
+	for _, document := range documents {
+		users = append(users, *document.toUser())
+	}
+
+	return NewPage(users, total, params)
+}
+
+func (u *UserDao) Get(ctx context.Context, id string) (*User, error) {
+	objectId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.findOne(ctx, bson.M{"_id": objectId})
+}
+
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return u.findOne(ctx, bson.M{"email": email})
+}
+
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+type UserCreatePayload struct {
+	FirstName *string
+	LastName  *string
+	Email     string
+	Password  string
+}
+
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
+	document := userDocument{
+		Id:        bson.NewObjectID(),
+		FirstName: payload.FirstName,
+		LastName:  payload.LastName,
+		Email:     payload.Email,
+		Password:  payload.Password,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := u.collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toUser(), nil
+}
+
+// @alchemy block {{- end }}
+
+type UserUpdatePayload struct {
+	FirstName *string
+	LastName  *string
+	Email     *string
+	Password  *string
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string
+	MfaEnabled *bool
+	// @alchemy block {{- end }}
+}
+
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	objectId, err := parseId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []struct {
+		key   string
+		value *string
+	}{
+		{"firstName", payload.FirstName},
+		{"lastName", payload.LastName},
+		{"email", payload.Email},
+		{"password", payload.Password},
+		// @alchemy block {{- if .MFA }}
+		{"mfaSecret", payload.MfaSecret},
+		// @alchemy block {{- end }}
+	}
+
+	set := bson.M{}
+	for _, field := range fields {
+		if field.value != nil {
+			set[field.key] = *field.value
+		}
+	}
+
+	// @alchemy block {{- if .EmailVerification }}
+
+	if payload.EmailVerifiedAt != nil {
+		set["emailVerifiedAt"] = *payload.EmailVerifiedAt
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+
+	if payload.MfaEnabled != nil {
+		set["mfaEnabled"] = *payload.MfaEnabled
+	}
+	// @alchemy block {{- end }}
+
+	if len(set) == 0 {
+		return u.Get(ctx, id)
+	}
+
+	// @alchemy block {{ if .Timestamps }}
+	set["updatedAt"] = time.Now()
+	// @alchemy block {{- end }}
+
+	document := userDocument{}
+	err = u.collection.FindOneAndUpdate(
+		ctx,
+		// @alchemy replace bson.M{"_id": objectId{{ if .SoftDelete }}, "deletedAt": nil{{ end }}},
+		bson.M{"_id": objectId},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&document)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return document.toUser(), nil
+}
+
+// @alchemy block {{- if .MFA }}
+
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// $not matches the users stored before MFA was added too, which have no
+	// mfaTotpStep
+	// @alchemy replace result, err := u.collection.UpdateOne(ctx, bson.M{"_id": objectId, "mfaTotpStep": bson.M{"$not": bson.M{"$gte": step}}}, bson.M{"$set": bson.M{"mfaTotpStep": step{{ if .Timestamps }}, "updatedAt": time.Now(){{ end }}}})
+	result, err := u.collection.UpdateOne(ctx, bson.M{"_id": objectId, "mfaTotpStep": bson.M{"$not": bson.M{"$gte": step}}}, bson.M{"$set": bson.M{"mfaTotpStep": step}})
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// @alchemy block {{- end }}
+
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy block {{ if .SoftDelete }}
+	_, err = u.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectId, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
+	// @alchemy block {{- else }}
+	_, err = u.collection.DeleteOne(ctx, bson.M{"_id": objectId})
+	// @alchemy block {{- end }}
+	return translateError(err)
+}
+
+// @alchemy block {{- if .SoftDelete }}
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.M{"$unset": bson.M{"deletedAt": ""}})
+	return translateError(err)
+}
+
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	objectId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.collection.DeleteOne(ctx, bson.M{"_id": objectId})
+	return translateError(err)
+}
+
+// @alchemy block {{- end }}
+
+// NewUserDao uses the `users` collection of database and makes sure its unique
+// email index exists.
+func NewUserDao(database *mongo.Database) (IUserDao, error) {
+	ctx := context.Background()
+
+	collection := database.Collection("users")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{bson.E{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDao{collection: collection}, nil
+}
@@ -0,0 +1,212 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	Id     string `json:"id"`
+	UserId string `json:"userId"`
+	Name   string `json:"name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	Revoked    bool       `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (ApiKey) fromModel(apiKey *db.ApiKeyModel) *ApiKey {
+	if apiKey == nil {
+		return nil
+	}
+
+	result := &ApiKey{
+		Id:         apiKey.ID,
+		UserId:     apiKey.UserID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		SecretHash: apiKey.SecretHash,
+		Scope:      apiKey.Scope,
+		Revoked:    apiKey.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: apiKey.CreatedAt,
+		UpdatedAt: apiKey.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+
+	if expiresAt, ok := apiKey.ExpiresAt(); ok {
+		result.ExpiresAt = &expiresAt
+	}
+
+	if lastUsedAt, ok := apiKey.LastUsedAt(); ok {
+		result.LastUsedAt = &lastUsedAt
+	}
+
+	return result
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	client *db.PrismaClient
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the api key is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: api keys can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.ApiKey.CreateOne(
+		db.ApiKey.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.ApiKey.Name.Set(payload.Name),
+		db.ApiKey.Prefix.Set(payload.Prefix),
+		db.ApiKey.SecretHash.Set(payload.SecretHash),
+		db.ApiKey.Scope.Set(payload.Scope),
+		db.ApiKey.ExpiresAt.SetIfPresent(payload.ExpiresAt),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.ApiKey.CreateOne(
+		db.ApiKey.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.ApiKey.Name.Set(payload.Name),
+		db.ApiKey.Prefix.Set(payload.Prefix),
+		db.ApiKey.SecretHash.Set(payload.SecretHash),
+		db.ApiKey.Scope.Set(payload.Scope),
+		db.ApiKey.ID.Set(id),
+		db.ApiKey.ExpiresAt.SetIfPresent(payload.ExpiresAt),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &ApiKey{
+			Id:         id,
+			UserId:     payload.UserId,
+			Name:       payload.Name,
+			Prefix:     payload.Prefix,
+			SecretHash: payload.SecretHash,
+			Scope:      payload.Scope,
+			ExpiresAt:  payload.ExpiresAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	apiKey, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (*ApiKey, error) {
+	apiKey, err := r.client.ApiKey.FindUnique(db.ApiKey.ID.Equals(id)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	apiKey, err := r.client.ApiKey.FindUnique(db.ApiKey.Prefix.Equals(prefix)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return ApiKey{}.fromModel(apiKey), nil
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	models, err := r.client.ApiKey.FindMany(db.ApiKey.UserID.Equals(userId)).
+		OrderBy(db.ApiKey.Name.Order(db.SortOrderAsc)).
+		Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	apiKeys := []ApiKey{}
+	for _, model := range models {
+		apiKeys = append(apiKeys, *ApiKey{}.fromModel(&model))
+	}
+
+	return apiKeys, nil
+}
+
+// Revoke can't tell whether the api key was already revoked within a
+// transaction, as the update only runs once the transaction commits
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	query := r.client.ApiKey.FindMany(
+		db.ApiKey.ID.Equals(id),
+		db.ApiKey.Revoked.Equals(false),
+	).Update(db.ApiKey.Revoked.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	query := r.client.ApiKey.FindUnique(db.ApiKey.ID.Equals(id)).Update(db.ApiKey.LastUsedAt.Set(lastUsedAt))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewApiKeyDao(client *db.PrismaClient) IApiKeyDao {
+	return &ApiKeyDao{client: client}
+}
@@ -0,0 +1,30 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"errors"
+	"fmt"
+
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// translateError converts the errors of the prisma client to the errors of the
+// dao package
+func translateError(err error) error {
+	if err == nil || IsTranslated(err) {
+		return err
+	}
+
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	if _, isUnique := db.IsErrUniqueConstraint(err); isUnique {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	return err
+}
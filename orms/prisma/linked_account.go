@@ -0,0 +1,151 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	Id             string `json:"id"`
+	UserId         string `json:"userId"`
+	Provider       string `json:"provider"`
+	ProviderUserId string `json:"providerUserId"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (LinkedAccount) fromModel(linkedAccount *db.LinkedAccountModel) *LinkedAccount {
+	if linkedAccount == nil {
+		return nil
+	}
+
+	return &LinkedAccount{
+		Id:             linkedAccount.ID,
+		UserId:         linkedAccount.UserID,
+		Provider:       linkedAccount.Provider,
+		ProviderUserId: linkedAccount.ProviderUserID,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: linkedAccount.CreatedAt,
+		UpdatedAt: linkedAccount.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	client *db.PrismaClient
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the linked account is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: linked accounts can't be created within a transaction", ErrInvalid)
+	}
+
+	query := l.client.LinkedAccount.CreateOne(
+		db.LinkedAccount.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.LinkedAccount.Provider.Set(payload.Provider),
+		db.LinkedAccount.ProviderUserID.Set(payload.ProviderUserId),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := l.client.LinkedAccount.CreateOne(
+		db.LinkedAccount.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.LinkedAccount.Provider.Set(payload.Provider),
+		db.LinkedAccount.ProviderUserID.Set(payload.ProviderUserId),
+		db.LinkedAccount.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &LinkedAccount{
+			Id:             id,
+			UserId:         payload.UserId,
+			Provider:       payload.Provider,
+			ProviderUserId: payload.ProviderUserId,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	linkedAccount, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return LinkedAccount{}.fromModel(linkedAccount), nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error) {
+	linkedAccount, err := l.client.LinkedAccount.FindFirst(
+		db.LinkedAccount.Provider.Equals(provider),
+		db.LinkedAccount.ProviderUserID.Equals(providerUserId),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return LinkedAccount{}.fromModel(linkedAccount), nil
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, userId string) ([]LinkedAccount, error) {
+	models, err := l.client.LinkedAccount.FindMany(db.LinkedAccount.UserID.Equals(userId)).
+		OrderBy(db.LinkedAccount.Provider.Order(db.SortOrderAsc)).
+		Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	linkedAccounts := []LinkedAccount{}
+	for _, model := range models {
+		linkedAccounts = append(linkedAccounts, *LinkedAccount{}.fromModel(&model))
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	query := l.client.LinkedAccount.FindUnique(db.LinkedAccount.ID.Equals(id)).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewLinkedAccountDao(client *db.PrismaClient) ILinkedAccountDao {
+	return &LinkedAccountDao{client: client}
+}
@@ -0,0 +1,155 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	Id        string    `json:"id"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (MagicLinkToken) fromModel(magicLinkToken *db.MagicLinkTokenModel) *MagicLinkToken {
+	if magicLinkToken == nil {
+		return nil
+	}
+
+	return &MagicLinkToken{
+		Id:        magicLinkToken.ID,
+		Email:     magicLinkToken.Email,
+		TokenHash: magicLinkToken.TokenHash,
+		ExpiresAt: magicLinkToken.ExpiresAt,
+		Used:      magicLinkToken.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: magicLinkToken.CreatedAt,
+		UpdatedAt: magicLinkToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	client *db.PrismaClient
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the magic link token is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: magic link tokens can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.MagicLinkToken.CreateOne(
+		db.MagicLinkToken.Email.Set(payload.Email),
+		db.MagicLinkToken.TokenHash.Set(payload.TokenHash),
+		db.MagicLinkToken.ExpiresAt.Set(payload.ExpiresAt),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.MagicLinkToken.CreateOne(
+		db.MagicLinkToken.Email.Set(payload.Email),
+		db.MagicLinkToken.TokenHash.Set(payload.TokenHash),
+		db.MagicLinkToken.ExpiresAt.Set(payload.ExpiresAt),
+		db.MagicLinkToken.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &MagicLinkToken{
+			Id:        id,
+			Email:     payload.Email,
+			TokenHash: payload.TokenHash,
+			ExpiresAt: payload.ExpiresAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	magicLinkToken, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return MagicLinkToken{}.fromModel(magicLinkToken), nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*MagicLinkToken, error) {
+	magicLinkToken, err := r.client.MagicLinkToken.FindUnique(db.MagicLinkToken.TokenHash.Equals(tokenHash)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return MagicLinkToken{}.fromModel(magicLinkToken), nil
+}
+
+// Use can't tell whether the magic link token was already used within a
+// transaction, as the update only runs once the transaction commits
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	query := r.client.MagicLinkToken.FindMany(
+		db.MagicLinkToken.ID.Equals(id),
+		db.MagicLinkToken.Used.Equals(false),
+	).Update(db.MagicLinkToken.Used.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	query := r.client.MagicLinkToken.FindMany(db.MagicLinkToken.Email.Equals(email)).Update(db.MagicLinkToken.Used.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewMagicLinkTokenDao(client *db.PrismaClient) IMagicLinkTokenDao {
+	return &MagicLinkTokenDao{client: client}
+}
@@ -0,0 +1,155 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Used      bool      `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (PasswordResetToken) fromModel(passwordResetToken *db.PasswordResetTokenModel) *PasswordResetToken {
+	if passwordResetToken == nil {
+		return nil
+	}
+
+	return &PasswordResetToken{
+		Id:        passwordResetToken.ID,
+		UserId:    passwordResetToken.UserID,
+		TokenHash: passwordResetToken.TokenHash,
+		ExpiresAt: passwordResetToken.ExpiresAt,
+		Used:      passwordResetToken.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: passwordResetToken.CreatedAt,
+		UpdatedAt: passwordResetToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	client *db.PrismaClient
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the password reset token is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: password reset tokens can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.PasswordResetToken.CreateOne(
+		db.PasswordResetToken.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.PasswordResetToken.TokenHash.Set(payload.TokenHash),
+		db.PasswordResetToken.ExpiresAt.Set(payload.ExpiresAt),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.PasswordResetToken.CreateOne(
+		db.PasswordResetToken.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.PasswordResetToken.TokenHash.Set(payload.TokenHash),
+		db.PasswordResetToken.ExpiresAt.Set(payload.ExpiresAt),
+		db.PasswordResetToken.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &PasswordResetToken{
+			Id:        id,
+			UserId:    payload.UserId,
+			TokenHash: payload.TokenHash,
+			ExpiresAt: payload.ExpiresAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	passwordResetToken, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return PasswordResetToken{}.fromModel(passwordResetToken), nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	passwordResetToken, err := r.client.PasswordResetToken.FindUnique(db.PasswordResetToken.TokenHash.Equals(tokenHash)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return PasswordResetToken{}.fromModel(passwordResetToken), nil
+}
+
+// Use can't tell whether the password reset token was already used within a
+// transaction, as the update only runs once the transaction commits
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	query := r.client.PasswordResetToken.FindMany(
+		db.PasswordResetToken.ID.Equals(id),
+		db.PasswordResetToken.Used.Equals(false),
+	).Update(db.PasswordResetToken.Used.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, userId string) error {
+	query := r.client.PasswordResetToken.FindMany(db.PasswordResetToken.UserID.Equals(userId)).Update(db.PasswordResetToken.Used.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewPasswordResetTokenDao(client *db.PrismaClient) IPasswordResetTokenDao {
+	return &PasswordResetTokenDao{client: client}
+}
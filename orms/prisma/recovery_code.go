@@ -0,0 +1,140 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	Id       string `json:"id"`
+	UserId   string `json:"userId"`
+	CodeHash string `json:"-"`
+	Used     bool   `json:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (RecoveryCode) fromModel(recoveryCode *db.RecoveryCodeModel) *RecoveryCode {
+	if recoveryCode == nil {
+		return nil
+	}
+
+	return &RecoveryCode{
+		Id:       recoveryCode.ID,
+		UserId:   recoveryCode.UserID,
+		CodeHash: recoveryCode.CodeHash,
+		Used:     recoveryCode.Used,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: recoveryCode.CreatedAt,
+		UpdatedAt: recoveryCode.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	client *db.PrismaClient
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the recovery code is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: recovery codes can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.RecoveryCode.CreateOne(
+		db.RecoveryCode.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.RecoveryCode.CodeHash.Set(payload.CodeHash),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.RecoveryCode.CreateOne(
+		db.RecoveryCode.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.RecoveryCode.CodeHash.Set(payload.CodeHash),
+		db.RecoveryCode.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &RecoveryCode{Id: id, UserId: payload.UserId, CodeHash: payload.CodeHash}, nil
+	}
+	// @alchemy block {{- end }}
+
+	recoveryCode, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RecoveryCode{}.fromModel(recoveryCode), nil
+}
+
+// Use can't tell whether the recovery code was already used within a
+// transaction, as the update only runs once the transaction commits
+func (r *RecoveryCodeDao) Use(ctx context.Context, userId string, codeHash string) error {
+	query := r.client.RecoveryCode.FindMany(
+		db.RecoveryCode.UserID.Equals(userId),
+		db.RecoveryCode.CodeHash.Equals(codeHash),
+		db.RecoveryCode.Used.Equals(false),
+	).Update(db.RecoveryCode.Used.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	query := r.client.RecoveryCode.FindMany(db.RecoveryCode.UserID.Equals(userId)).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewRecoveryCodeDao(client *db.PrismaClient) IRecoveryCodeDao {
+	return &RecoveryCodeDao{client: client}
+}
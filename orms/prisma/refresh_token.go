@@ -0,0 +1,160 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	FamilyId  string    `json:"familyId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (RefreshToken) fromModel(refreshToken *db.RefreshTokenModel) *RefreshToken {
+	if refreshToken == nil {
+		return nil
+	}
+
+	return &RefreshToken{
+		Id:        refreshToken.ID,
+		UserId:    refreshToken.UserID,
+		FamilyId:  refreshToken.FamilyID,
+		TokenHash: refreshToken.TokenHash,
+		ExpiresAt: refreshToken.ExpiresAt,
+		Revoked:   refreshToken.Revoked,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: refreshToken.CreatedAt,
+		UpdatedAt: refreshToken.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	client *db.PrismaClient
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the refresh token is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: refresh tokens can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.RefreshToken.CreateOne(
+		db.RefreshToken.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.RefreshToken.FamilyID.Set(payload.FamilyId),
+		db.RefreshToken.TokenHash.Set(payload.TokenHash),
+		db.RefreshToken.ExpiresAt.Set(payload.ExpiresAt),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.RefreshToken.CreateOne(
+		db.RefreshToken.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.RefreshToken.FamilyID.Set(payload.FamilyId),
+		db.RefreshToken.TokenHash.Set(payload.TokenHash),
+		db.RefreshToken.ExpiresAt.Set(payload.ExpiresAt),
+		db.RefreshToken.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &RefreshToken{
+			Id:        id,
+			UserId:    payload.UserId,
+			FamilyId:  payload.FamilyId,
+			TokenHash: payload.TokenHash,
+			ExpiresAt: payload.ExpiresAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	refreshToken, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RefreshToken{}.fromModel(refreshToken), nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	refreshToken, err := r.client.RefreshToken.FindUnique(db.RefreshToken.TokenHash.Equals(tokenHash)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RefreshToken{}.fromModel(refreshToken), nil
+}
+
+// Revoke can't tell whether the refresh token was already revoked within a
+// transaction, as the update only runs once the transaction commits
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	query := r.client.RefreshToken.FindMany(
+		db.RefreshToken.ID.Equals(id),
+		db.RefreshToken.Revoked.Equals(false),
+	).Update(db.RefreshToken.Revoked.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	query := r.client.RefreshToken.FindMany(db.RefreshToken.FamilyID.Equals(familyId)).Update(db.RefreshToken.Revoked.Set(true))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewRefreshTokenDao(client *db.PrismaClient) IRefreshTokenDao {
+	return &RefreshTokenDao{client: client}
+}
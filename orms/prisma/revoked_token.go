@@ -0,0 +1,150 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	"errors"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	Jti       *string   `json:"jti"`
+	RevokedAt time.Time `json:"revokedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (RevokedToken) fromModel(revokedToken *db.RevokedTokenModel) *RevokedToken {
+	if revokedToken == nil {
+		return nil
+	}
+
+	var jti *string
+	if value, ok := revokedToken.Jti(); ok {
+		jti = &value
+	}
+
+	return &RevokedToken{
+		Id:        revokedToken.ID,
+		UserId:    revokedToken.UserID,
+		Jti:       jti,
+		RevokedAt: revokedToken.RevokedAt,
+		ExpiresAt: revokedToken.ExpiresAt,
+	}
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	client *db.PrismaClient
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	revokedAt := time.Now()
+
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the revoked token is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: revoked tokens can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.RevokedToken.CreateOne(
+		db.RevokedToken.UserID.Set(payload.UserId),
+		db.RevokedToken.RevokedAt.Set(revokedAt),
+		db.RevokedToken.ExpiresAt.Set(payload.ExpiresAt),
+		db.RevokedToken.Jti.SetIfPresent(payload.Jti),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.RevokedToken.CreateOne(
+		db.RevokedToken.UserID.Set(payload.UserId),
+		db.RevokedToken.RevokedAt.Set(revokedAt),
+		db.RevokedToken.ExpiresAt.Set(payload.ExpiresAt),
+		db.RevokedToken.ID.Set(id),
+		db.RevokedToken.Jti.SetIfPresent(payload.Jti),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &RevokedToken{
+			Id:        id,
+			UserId:    payload.UserId,
+			Jti:       payload.Jti,
+			RevokedAt: revokedAt,
+			ExpiresAt: payload.ExpiresAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	revokedToken, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return RevokedToken{}.fromModel(revokedToken), nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	_, err := r.client.RevokedToken.FindFirst(
+		db.RevokedToken.Or(
+			db.RevokedToken.Jti.Equals(jti),
+			db.RevokedToken.And(
+				db.RevokedToken.Jti.IsNull(),
+				db.RevokedToken.UserID.Equals(userId),
+				db.RevokedToken.RevokedAt.Gt(issuedAt),
+			),
+		),
+	).Exec(ctx)
+	if errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return true, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	query := r.client.RevokedToken.FindMany(db.RevokedToken.ExpiresAt.Lt(time.Now())).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewRevokedTokenDao(client *db.PrismaClient) IRevokedTokenDao {
+	return &RevokedTokenDao{client: client}
+}
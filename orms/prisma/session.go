@@ -0,0 +1,170 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"fmt"
+	// @alchemy block {{- end }}
+	"time"
+
+	// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+}
+
+func (Session) fromModel(session *db.SessionModel) *Session {
+	if session == nil {
+		return nil
+	}
+
+	return &Session{
+		Id:         session.ID,
+		UserId:     session.UserID,
+		TokenHash:  session.TokenHash,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: session.CreatedAt,
+		UpdatedAt: session.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	client *db.PrismaClient
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the session is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: sessions can't be created within a transaction", ErrInvalid)
+	}
+
+	query := r.client.Session.CreateOne(
+		db.Session.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.Session.TokenHash.Set(payload.TokenHash),
+		db.Session.ExpiresAt.Set(payload.ExpiresAt),
+		db.Session.LastSeenAt.Set(payload.LastSeenAt),
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := r.client.Session.CreateOne(
+		db.Session.User.Link(db.User.ID.Equals(payload.UserId)),
+		db.Session.TokenHash.Set(payload.TokenHash),
+		db.Session.ExpiresAt.Set(payload.ExpiresAt),
+		db.Session.LastSeenAt.Set(payload.LastSeenAt),
+		db.Session.ID.Set(id),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &Session{
+			Id:         id,
+			UserId:     payload.UserId,
+			TokenHash:  payload.TokenHash,
+			ExpiresAt:  payload.ExpiresAt,
+			LastSeenAt: payload.LastSeenAt,
+		}, nil
+	}
+	// @alchemy block {{- end }}
+
+	session, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return Session{}.fromModel(session), nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	session, err := r.client.Session.FindUnique(db.Session.TokenHash.Equals(tokenHash)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return Session{}.fromModel(session), nil
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	query := r.client.Session.FindUnique(db.Session.ID.Equals(id)).Update(db.Session.LastSeenAt.Set(lastSeenAt))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	query := r.client.Session.FindMany(db.Session.ID.Equals(id)).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	query := r.client.Session.FindMany(db.Session.UserID.Equals(userId)).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	query := r.client.Session.FindMany(db.Session.ExpiresAt.Lt(time.Now())).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+func NewSessionDao(client *db.PrismaClient) ISessionDao {
+	return &SessionDao{client: client}
+}
@@ -0,0 +1,63 @@
+// @alchemy replace package dao
+package prisma
+
+import (
+	"context"
+
+	// @alchemy statement "{{ .ModuleName }}/prisma/db"
+	"github.com/struckchure/go-alchemy/prisma/db"
+)
+
+type txKey struct{}
+
+// prismaTx holds the writes made within a transaction
+type prismaTx struct {
+	queries []db.PrismaTransaction
+}
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. Nothing is written if fn returns an error,
+	// nested calls join the outer transaction.
+	//
+	// Prisma runs a transaction as one batch, so the writes of the DAOs are
+	// queued and run after fn returns. Reads within fn don't see them, and the
+	// records returned by writes are built from their payloads.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client *db.PrismaClient
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &prismaTx{}
+	err := fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	if len(tx.queries) == 0 {
+		return nil
+	}
+
+	return t.client.Prisma.Transaction(tx.queries...).Exec(ctx)
+}
+
+// enqueue adds query to the transaction of ctx, it returns false if ctx has none
+func enqueue(ctx context.Context, query db.PrismaTransaction) bool {
+	tx, ok := ctx.Value(txKey{}).(*prismaTx)
+	if ok {
+		tx.queries = append(tx.queries, query)
+	}
+
+	return ok
+}
+
+func NewTxManager(client *db.PrismaClient) ITxManager {
+	return &TxManager{client: client}
+}
@@ -3,18 +3,51 @@ package prisma
 
 import (
 	"context"
-	"errors"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"encoding/json"
+	// @alchemy block {{- end }}
+	"fmt"
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	"regexp"
+	// @alchemy block {{- else }}
+	"strings"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if or .Timestamps .SoftDelete .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
 
+	// @alchemy block {{- if and (or .Register .OAuth .MagicLink) (ne .DatabaseProvider "mongodb") }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
 	// @alchemy statement "{{ .ModuleName }}/prisma/db"
 	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
 )
 
 type User struct {
-	Id        string `json:"id"`
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Email     string `json:"email"`
-	Password  string `json:"-"`
+	Id        string  `json:"id"`
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+	Email     string  `json:"email"`
+	Password  string  `json:"-"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-"`
+	MfaEnabled bool    `json:"mfaEnabled"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `json:"deletedAt"`
+	// @alchemy block {{- end }}
 }
 
 func (User) fromModel(user *db.UserModel) *User {
@@ -22,65 +55,375 @@ func (User) fromModel(user *db.UserModel) *User {
 		return nil
 	}
 
-	firstName, _ := user.FirstName()
-	lastName, _ := user.FirstName()
+	result := &User{
+		Id:       user.ID,
+		Email:    user.Email,
+		Password: user.Password,
+		// @alchemy block {{- if .MFA }}
+		MfaEnabled:  user.MfaEnabled,
+		MfaTotpStep: int64(user.MfaTotpStep),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		// @alchemy block {{- end }}
+	}
+
+	if firstName, ok := user.FirstName(); ok {
+		result.FirstName = &firstName
+	}
+
+	if lastName, ok := user.LastName(); ok {
+		result.LastName = &lastName
+	}
+
+	// @alchemy block {{ if .EmailVerification }}
+	if emailVerifiedAt, ok := user.EmailVerifiedAt(); ok {
+		result.EmailVerifiedAt = &emailVerifiedAt
+	}
+	// @alchemy block {{- end }}
 
-	return &User{
-		Id:        user.ID,
-		FirstName: firstName,
-		LastName:  lastName,
-		Email:     user.Email,
-		Password:  user.Password,
+	// @alchemy block {{ if .MFA }}
+	if mfaSecret, ok := user.MfaSecret(); ok {
+		result.MfaSecret = &mfaSecret
+	}
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{ if .SoftDelete }}
+	if deletedAt, ok := user.DeletedAt(); ok {
+		result.DeletedAt = &deletedAt
+	}
+	// @alchemy block {{- end }}
+
+	return result
+}
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        "id",
+	"firstName": "firstName",
+	"lastName":  "lastName",
+	"email":     "email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": "createdAt",
+	"updatedAt": "updatedAt",
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+// userWhere returns the where param of filter, its value was decoded into the
+// type of its field by Normalize
+func userWhere(filter Filter) (db.UserWhereParam, error) {
+	// @alchemy block {{- if .Timestamps }}
+	if at, ok := filter.Value.(time.Time); ok {
+		if filter.Field == "createdAt" {
+			return db.User.CreatedAt.Equals(at), nil
+		}
+
+		return db.User.UpdatedAt.Equals(at), nil
+	}
+
+	// @alchemy block {{- end }}
+	value, ok := filter.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: `%s` must be a string", ErrInvalidListParams, filter.Field)
+	}
+
+	contains := filter.Operator == FilterContains
+	switch filter.Field {
+	case "id":
+		if contains {
+			return db.User.ID.Contains(value), nil
+		}
+
+		return db.User.ID.Equals(value), nil
+	case "firstName":
+		if contains {
+			return db.User.FirstName.Contains(value), nil
+		}
+
+		return db.User.FirstName.Equals(value), nil
+	case "lastName":
+		if contains {
+			return db.User.LastName.Contains(value), nil
+		}
+
+		return db.User.LastName.Equals(value), nil
+	default:
+		if contains {
+			return db.User.Email.Contains(value), nil
+		}
+
+		return db.User.Email.Equals(value), nil
+	}
+}
+
+func userOrderBy(sort Sort) db.UserOrderByParam {
+	order := db.SortOrderAsc
+	if sort.Order == SortDesc {
+		order = db.SortOrderDesc
+	}
+
+	switch sort.Field {
+	case "email":
+		return db.User.Email.Order(order)
+	// @alchemy block {{- if .Timestamps }}
+	case "createdAt":
+		return db.User.CreatedAt.Order(order)
+	case "updatedAt":
+		return db.User.UpdatedAt.Order(order)
+	// @alchemy block {{- end }}
+	default:
+		return db.User.ID.Order(order)
 	}
 }
 
+// @alchemy block {{- if ne .DatabaseProvider "mongodb" }}
+// rawIdentifier quotes a table or column name of a raw query
+func rawIdentifier(name string) string {
+	// @alchemy replace return {{ if eq .DatabaseProvider "mysql" }}"`" + name + "`"{{ else if eq .DatabaseProvider "sqlserver" }}"[" + name + "]"{{ else }}`"` + name + `"`{{ end }}
+	return `"` + name + `"`
+}
+
+// rawPlaceholder is the placeholder of the nth parameter of a raw query
+func rawPlaceholder(n int) string {
+	// @alchemy replace return {{ if or (eq .DatabaseProvider "postgresql") (eq .DatabaseProvider "cockroachdb") }}fmt.Sprintf("$%d", n){{ else if eq .DatabaseProvider "sqlserver" }}fmt.Sprintf("@P%d", n){{ else }}"?"{{ end }}
+	return fmt.Sprintf("$%d", n)
+}
+
+// @alchemy block {{- end }}
 type UserUpdatePayload struct {
 	FirstName *string
 	LastName  *string
 	Email     *string
 	Password  *string
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string
+	MfaEnabled *bool
+	// @alchemy block {{- end }}
 }
 
 type IUserDao interface {
-	List() ([]User, error)
-	// @alchemy block {{- if .Login }}
-	Get(string) (*User, error)
-	GetByEmail(string) (*User, error)
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
 	// @alchemy block {{- end }}
-	// @alchemy block {{- if .Register }}
-	Create(UserCreatePayload) (*User, error)
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
+	// @alchemy block {{- end }}
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
 	// @alchemy block {{- end }}
-	Update(string, UserUpdatePayload) (*User, error)
-	Delete(string) error
 }
 
 type UserDao struct {
 	client *db.PrismaClient
 }
 
-func (u *UserDao) List() ([]User, error) {
-	panic("unimplemented")
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	params = *normalizedParams
+
+	where := []db.UserWhereParam{}
+	// @alchemy block {{- if .SoftDelete }}
+	if !params.WithDeleted {
+		where = append(where, db.User.DeletedAt.IsNull())
+	}
+	// @alchemy block {{- end }}
+
+	for _, filter := range params.Filters {
+		param, err := userWhere(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		where = append(where, param)
+	}
+
+	orderBy := []db.UserOrderByParam{}
+	for _, sort := range params.Sort {
+		orderBy = append(orderBy, userOrderBy(sort))
+	}
+
+	query := u.client.User.FindMany(where...).OrderBy(orderBy...).Take(params.Limit + 1)
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		// prisma pages from the cursor record in the order of the query, so only its id is used
+		for i, sort := range params.Sort {
+			if id, ok := values[i].(string); ok && sort.Field == "id" {
+				query = query.Cursor(db.User.ID.Cursor(id)).Skip(1)
+			}
+		}
+	} else {
+		query = query.Skip(params.Offset)
+	}
+
+	users, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	total, err := u.count(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]User, 0, len(users))
+	for _, user := range users {
+		result = append(result, *User{}.fromModel(&user))
+	}
+
+	return NewPage(result, total, params)
 }
 
-// @alchemy block {{- if .Login }}
-func (u *UserDao) Get(id string) (*User, error) {
-	panic("unimplemented")
+// count counts the users matching the filters of params, which were validated
+// by userWhere. The prisma client has no count query, so it's a raw one.
+func (u *UserDao) count(ctx context.Context, params ListParams) (int64, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	conditions := []map[string]any{}
+	// @alchemy block {{- if .SoftDelete }}
+	if !params.WithDeleted {
+		conditions = append(conditions, map[string]any{"deletedAt": nil})
+	}
+	// @alchemy block {{- end }}
+
+	for _, filter := range params.Filters {
+		field := userFields[filter.Field]
+		if field == "id" {
+			field = "_id"
+		}
+
+		var value any = filter.Value
+		switch {
+		case filter.Operator == FilterContains:
+			value = map[string]any{"$regex": regexp.QuoteMeta(filter.Value.(string))}
+		// @alchemy block {{- if .Timestamps }}
+		case field == "createdAt" || field == "updatedAt":
+			value = map[string]any{"$date": filter.Value}
+		// @alchemy block {{- end }}
+		case field == "_id":
+			value = map[string]any{"$oid": filter.Value}
+		}
+
+		conditions = append(conditions, map[string]any{field: value})
+	}
+
+	query := map[string]any{}
+	if len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+
+	command, err := json.Marshal(map[string]any{"count": "User", "query": query})
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		N int64 `json:"n"`
+	}
+	err = u.client.Prisma.RunCommandRaw(string(command)).Exec(ctx, &result)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	return result.N, nil
+	// @alchemy block {{- else }}
+	conditions := []string{}
+	args := []any{}
+	// @alchemy block {{- if .SoftDelete }}
+	if !params.WithDeleted {
+		conditions = append(conditions, rawIdentifier("deletedAt")+" IS NULL")
+	}
+	// @alchemy block {{- end }}
+
+	for _, filter := range params.Filters {
+		column := rawIdentifier(userFields[filter.Field])
+		// @alchemy block {{- if or (eq .DatabaseProvider "postgresql") (eq .DatabaseProvider "cockroachdb") }}
+		// the id is a uuid, the filter value is text
+		if filter.Field == "id" {
+			column = "CAST(" + column + " AS TEXT)"
+		}
+		// @alchemy block {{- end }}
+
+		placeholder := rawPlaceholder(len(args) + 1)
+		switch {
+		case filter.Operator == FilterContains:
+			conditions = append(conditions, column+" LIKE "+placeholder+likeEscape)
+			args = append(args, "%"+likeEscaper.Replace(filter.Value.(string))+"%")
+		default:
+			conditions = append(conditions, column+" = "+placeholder)
+			args = append(args, filter.Value)
+		}
+	}
+
+	query := "SELECT COUNT(*) AS total FROM " + rawIdentifier("User")
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var result []struct {
+		Total db.RawBigInt `json:"total"`
+	}
+	err := u.client.Prisma.QueryRaw(query, args...).Exec(ctx, &result)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	return int64(result[0].Total), nil
+	// @alchemy block {{- end }}
 }
 
-func (u *UserDao) GetByEmail(email string) (*User, error) {
-	ctx := context.Background()
+// Get returns ErrNotFound if the user doesn't exist
+func (u *UserDao) Get(ctx context.Context, id string) (*User, error) {
+	// @alchemy replace user, err := u.client.User.{{ if .SoftDelete }}FindFirst(db.User.ID.Equals(id), db.User.DeletedAt.IsNull()){{ else }}FindUnique(db.User.ID.Equals(id)){{ end }}.Exec(ctx)
+	user, err := u.client.User.FindUnique(db.User.ID.Equals(id)).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return User{}.fromModel(user), nil
+}
 
+// GetByEmail returns ErrNotFound if the user doesn't exist
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (*User, error) {
+	// @alchemy replace user, err := u.client.User.{{ if .SoftDelete }}FindFirst(db.User.Email.Equals(email), db.User.DeletedAt.IsNull()){{ else }}FindUnique(db.User.Email.Equals(email)){{ end }}.Exec(ctx)
 	user, err := u.client.User.FindUnique(db.User.Email.Equals(email)).Exec(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
-	return User{}.fromModel(user), err
+	return User{}.fromModel(user), nil
 }
 
-// @alchemy block {{- end }}
-
-// @alchemy block {{- if .Register }}
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
 type UserCreatePayload struct {
 	FirstName *string
 	LastName  *string
@@ -88,37 +431,201 @@ type UserCreatePayload struct {
 	Password  string
 }
 
-func (u *UserDao) Create(payload UserCreatePayload) (*User, error) {
-	ctx := context.Background()
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
+	// @alchemy block {{- if eq .DatabaseProvider "mongodb" }}
+	// mongodb ids are only known once the user is created
+	if _, ok := ctx.Value(txKey{}).(*prismaTx); ok {
+		return nil, fmt.Errorf("%w: users can't be created within a transaction", ErrInvalid)
+	}
 
-	user, err := u.client.User.CreateOne(
+	query := u.client.User.CreateOne(
 		db.User.Email.Set(payload.Email),
 		db.User.Password.Set(payload.Password),
 		db.User.FirstName.SetIfPresent(payload.FirstName),
 		db.User.LastName.SetIfPresent(payload.LastName),
-	).Exec(ctx)
+	)
+	// @alchemy block {{- else }}
+	// the id is generated here, so it is known before a transaction commits
+	id := uuid.NewString()
+	query := u.client.User.CreateOne(
+		db.User.Email.Set(payload.Email),
+		db.User.Password.Set(payload.Password),
+		db.User.ID.Set(id),
+		db.User.FirstName.SetIfPresent(payload.FirstName),
+		db.User.LastName.SetIfPresent(payload.LastName),
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		return &User{
+			Id:        id,
+			FirstName: payload.FirstName,
+			LastName:  payload.LastName,
+			Email:     payload.Email,
+			Password:  payload.Password,
+		}, nil
+	}
+	// @alchemy block {{- end }}
 
+	user, err := query.Exec(ctx)
 	if err != nil {
-		if _, isUnique := db.IsErrUniqueConstraint(err); isUnique {
-			return nil, errors.New("record already exist")
-		}
+		return nil, translateError(err)
+	}
 
+	return User{}.fromModel(user), nil
+}
+
+// @alchemy block {{- end }}
+
+// Update only changes the fields set in payload, it returns ErrNotFound if
+// the user doesn't exist
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	// @alchemy block {{- if .SoftDelete }}
+	_, err := u.Get(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 
+	// @alchemy block {{ end }}
+	query := u.client.User.FindUnique(db.User.ID.Equals(id)).Update(
+		db.User.FirstName.SetIfPresent(payload.FirstName),
+		db.User.LastName.SetIfPresent(payload.LastName),
+		db.User.Email.SetIfPresent(payload.Email),
+		db.User.Password.SetIfPresent(payload.Password),
+		// @alchemy block {{- if .EmailVerification }}
+		db.User.EmailVerifiedAt.SetIfPresent(payload.EmailVerifiedAt),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+		db.User.MfaSecret.SetIfPresent(payload.MfaSecret),
+		db.User.MfaEnabled.SetIfPresent(payload.MfaEnabled),
+		// @alchemy block {{- end }}
+	)
+
+	if enqueue(ctx, query.Tx()) {
+		user, err := u.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if payload.FirstName != nil {
+			user.FirstName = payload.FirstName
+		}
+
+		if payload.LastName != nil {
+			user.LastName = payload.LastName
+		}
+
+		if payload.Email != nil {
+			user.Email = *payload.Email
+		}
+
+		if payload.Password != nil {
+			user.Password = *payload.Password
+		}
+		// @alchemy block {{- if .EmailVerification }}
+
+		if payload.EmailVerifiedAt != nil {
+			user.EmailVerifiedAt = payload.EmailVerifiedAt
+		}
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+
+		if payload.MfaSecret != nil {
+			user.MfaSecret = payload.MfaSecret
+		}
+
+		if payload.MfaEnabled != nil {
+			user.MfaEnabled = *payload.MfaEnabled
+		}
+		// @alchemy block {{- end }}
+
+		return user, nil
+	}
+
+	user, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
 	return User{}.fromModel(user), nil
 }
 
+// @alchemy block {{- if .MFA }}
+
+// UseMfaTotpStep can't tell whether the step was already used within a
+// transaction, as the update only runs once the transaction commits
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	query := u.client.User.FindMany(
+		db.User.ID.Equals(id),
+		db.User.MfaTotpStep.Lt(db.BigInt(step)),
+	).Update(db.User.MfaTotpStep.Set(db.BigInt(step)))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.Count == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 // @alchemy block {{- end }}
 
-func (u *UserDao) Update(id string, payload UserUpdatePayload) (*User, error) {
-	panic("unimplemented")
+// Delete returns ErrNotFound if the user doesn't exist
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	// @alchemy block {{- if .SoftDelete }}
+	_, err := u.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	query := u.client.User.FindUnique(db.User.ID.Equals(id)).Update(db.User.DeletedAt.Set(time.Now()))
+	// @alchemy block {{- else }}
+	query := u.client.User.FindUnique(db.User.ID.Equals(id)).Delete()
+	// @alchemy block {{- end }}
+
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	// @alchemy replace _, err {{ if .SoftDelete }}={{ else }}:={{ end }} query.Exec(ctx)
+	_, err = query.Exec(ctx)
+
+	return translateError(err)
 }
 
-func (u *UserDao) Delete(id string) error {
-	panic("unimplemented")
+// @alchemy block {{- if .SoftDelete }}
+// Restore returns ErrNotFound if the user doesn't exist
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	query := u.client.User.FindUnique(db.User.ID.Equals(id)).Update(db.User.DeletedAt.SetOptional(nil))
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
+}
+
+// HardDelete returns ErrNotFound if the user doesn't exist
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	query := u.client.User.FindUnique(db.User.ID.Equals(id)).Delete()
+	if enqueue(ctx, query.Tx()) {
+		return nil
+	}
+
+	_, err := query.Exec(ctx)
+
+	return translateError(err)
 }
 
+// @alchemy block {{- end }}
+
 func NewUserDao(client *db.PrismaClient) IUserDao {
 	return &UserDao{client: client}
 }
@@ -0,0 +1,22 @@
+// @alchemy replace package dao
+package shared
+
+import "errors"
+
+// The DAOs translate the errors of their database driver to these errors, so
+// callers don't depend on the orm. The driver error stays wrapped, it can still
+// be inspected with errors.Is and errors.As.
+var (
+	// ErrNotFound is returned when a record doesn't exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write violates a unique constraint
+	ErrConflict = errors.New("record already exists")
+	// ErrInvalid is returned when a write violates another constraint, or an
+	// argument (e.g. an id or list params) is malformed
+	ErrInvalid = errors.New("invalid input")
+)
+
+// IsTranslated reports whether err is already one of the errors of the dao package
+func IsTranslated(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalid)
+}
@@ -0,0 +1,374 @@
+// @alchemy replace package dao
+package shared
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultListLimit int = 20
+	MaxListLimit     int = 100
+)
+
+var ErrInvalidListParams = fmt.Errorf("%w: invalid list params", ErrInvalid)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// Sort orders a list by Field, the json name of a model field
+type Sort struct {
+	Field string    `json:"field"`
+	Order SortOrder `json:"order"`
+}
+
+type FilterOperator string
+
+const (
+	FilterEquals   FilterOperator = "eq"
+	FilterContains FilterOperator = "contains"
+)
+
+// Filter matches the records whose Field, the json name of a model field,
+// equals or contains Value
+type Filter struct {
+	Field    string         `json:"field"`
+	Operator FilterOperator `json:"operator"`
+	Value    any            `json:"value"`
+}
+
+// ListParams paginates, sorts and filters a List query.
+//
+// Cursor pagination is used when Cursor is set, Offset is ignored then.
+type ListParams struct {
+	Offset  int      `json:"offset"`
+	Limit   int      `json:"limit"`
+	Cursor  string   `json:"cursor"`
+	Sort    []Sort   `json:"sort"`
+	Filters []Filter `json:"filters"`
+	// @alchemy block {{- if .SoftDelete }}
+	// WithDeleted includes soft deleted records
+	WithDeleted bool `json:"withDeleted"`
+	// @alchemy block {{- end }}
+}
+
+// Page is a page of a List query
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// Total is the number of records matching the filters
+	Total int64 `json:"total"`
+	// NextCursor is the Cursor of the next page, it's empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Normalize validates params against model, a value of the listed model,
+// fields, which maps the json name of the filterable fields of the model to
+// their column, and sortFields, the fields which can be sorted by. Nullable
+// fields can't be sorted by, as a cursor compares the values of the sort
+// fields and NULL compares to nothing.
+//
+// The limit defaults to DefaultListLimit and the id is added as the last sort
+// field, so the order of a list and its cursors are stable. The filter values
+// are decoded into the type of their field, e.g a time.Time from its RFC 3339
+// string, so they're bound as that type.
+func (p ListParams) Normalize(model any, fields map[string]string, sortFields []string) (*ListParams, error) {
+	if p.Offset < 0 || p.Limit < 0 {
+		return nil, fmt.Errorf("%w: offset and limit can't be negative", ErrInvalidListParams)
+	}
+
+	if p.Limit == 0 {
+		p.Limit = DefaultListLimit
+	}
+
+	if p.Limit > MaxListLimit {
+		p.Limit = MaxListLimit
+	}
+
+	sorts := []Sort{}
+	sortedById := false
+	for _, sort := range p.Sort {
+		if !slices.Contains(sortFields, sort.Field) {
+			return nil, fmt.Errorf("%w: can't sort by `%s`", ErrInvalidListParams, sort.Field)
+		}
+
+		if sort.Order == "" {
+			sort.Order = SortAsc
+		}
+
+		if sort.Order != SortAsc && sort.Order != SortDesc {
+			return nil, fmt.Errorf("%w: unknown sort order `%s`", ErrInvalidListParams, sort.Order)
+		}
+
+		sortedById = sortedById || sort.Field == "id"
+		sorts = append(sorts, sort)
+	}
+
+	if !sortedById {
+		sorts = append(sorts, Sort{Field: "id", Order: SortAsc})
+	}
+
+	p.Sort = sorts
+
+	filters := []Filter{}
+	for _, filter := range p.Filters {
+		if _, ok := fields[filter.Field]; !ok {
+			return nil, fmt.Errorf("%w: can't filter by `%s`", ErrInvalidListParams, filter.Field)
+		}
+
+		fieldType, err := fieldType(model, filter.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		switch filter.Operator {
+		case FilterEquals:
+			if filter.Value != nil {
+				filter.Value, err = decodeValue(fieldType, filter.Field, filter.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case FilterContains:
+			if fieldType.Kind() != reflect.String {
+				return nil, fmt.Errorf("%w: `%s` can't be filtered with `%s`", ErrInvalidListParams, filter.Field, filter.Operator)
+			}
+
+			if _, ok := filter.Value.(string); !ok {
+				return nil, fmt.Errorf("%w: `%s` can only contain a string", ErrInvalidListParams, filter.Field)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown filter operator `%s`", ErrInvalidListParams, filter.Operator)
+		}
+
+		filters = append(filters, filter)
+	}
+
+	p.Filters = filters
+
+	return &p, nil
+}
+
+// fieldIndex returns the index of the field of the struct t with the json
+// name field
+func fieldIndex(t reflect.Type, field string) (int, error) {
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == field {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("field `%s` doesn't exist", field)
+}
+
+// fieldValue returns the value of the field of item with the json name field
+func fieldValue(item any, field string) (any, error) {
+	v := reflect.Indirect(reflect.ValueOf(item))
+
+	i, err := fieldIndex(v.Type(), field)
+	if err != nil {
+		return nil, err
+	}
+
+	value := v.Field(i)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, nil
+		}
+
+		value = value.Elem()
+	}
+
+	return value.Interface(), nil
+}
+
+// fieldType returns the type of the field of model with the json name field,
+// the type a nullable field points to for pointers
+func fieldType(model any, field string) (reflect.Type, error) {
+	t := reflect.Indirect(reflect.ValueOf(model)).Type()
+
+	i, err := fieldIndex(t, field)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldType := t.Field(i).Type
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType, nil
+}
+
+// decodeValue decodes value, decoded from json as a string, number or bool,
+// into fieldType. Drivers compare the values with the column as they're
+// bound, e.g a time as a string compares as text on sqlite.
+func decodeValue(fieldType reflect.Type, field string, value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid `%s` value", ErrInvalidListParams, field)
+	}
+
+	decoded := reflect.New(fieldType)
+	err = json.Unmarshal(data, decoded.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid `%s` value", ErrInvalidListParams, field)
+	}
+
+	return decoded.Elem().Interface(), nil
+}
+
+// EncodeCursor encodes the values of the sort fields of item, the last item
+// of a page
+func EncodeCursor(item any, sorts []Sort) (string, error) {
+	values := make([]any, 0, len(sorts))
+	for _, sort := range sorts {
+		value, err := fieldValue(item, sort.Field)
+		if err != nil {
+			return "", err
+		}
+
+		if value == nil {
+			return "", fmt.Errorf("can't sort by `%s`, it's null", sort.Field)
+		}
+
+		values = append(values, value)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes the values of the sort fields of a cursor created by
+// EncodeCursor into the types of the fields of model
+func DecodeCursor(model any, cursor string, sorts []Sort) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListParams)
+	}
+
+	var values []any
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListParams)
+	}
+
+	if len(values) != len(sorts) {
+		return nil, fmt.Errorf("%w: cursor doesn't match the sort", ErrInvalidListParams)
+	}
+
+	for i, sort := range sorts {
+		fieldType, err := fieldType(model, sort.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i], err = decodeValue(fieldType, sort.Field, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListParams)
+		}
+	}
+
+	return values, nil
+}
+
+// NewPage creates the page of a List query which fetched params.Limit+1
+// items, the extra item tells whether there's a next page
+func NewPage[T any](items []T, total int64, params ListParams) (*Page[T], error) {
+	page := &Page[T]{Items: items, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if len(items) > params.Limit {
+		page.Items = items[:params.Limit]
+
+		cursor, err := EncodeCursor(page.Items[params.Limit-1], params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		page.NextCursor = cursor
+	}
+
+	return page, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, along with the escape
+// character itself
+// @alchemy replace var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`{{ if eq .DatabaseProvider "sqlserver" }}, "[", `\[`{{ end }})
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// likeEscape is the ESCAPE clause of the LIKE conditions, mysql escapes `\`
+// within string literals too and clickhouse always escapes with `\`
+// @alchemy replace const likeEscape = {{ if eq .DatabaseProvider "mysql" }}` ESCAPE '\\'`{{ else if eq .DatabaseProvider "clickhouse" }}""{{ else }}` ESCAPE '\'`{{ end }}
+const likeEscape = ` ESCAPE '\'`
+
+// SqlFilters returns the `?` placeholder condition of filters, it's empty if
+// there are no filters
+func SqlFilters(filters []Filter, columns map[string]string) (string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	for _, filter := range filters {
+		switch filter.Operator {
+		case FilterEquals:
+			conditions = append(conditions, columns[filter.Field]+" = ?")
+			args = append(args, filter.Value)
+		case FilterContains:
+			conditions = append(conditions, columns[filter.Field]+" LIKE ?"+likeEscape)
+			args = append(args, "%"+likeEscaper.Replace(filter.Value.(string))+"%")
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// SqlKeyset returns the `?` placeholder condition matching the records after
+// the cursor values, in the order of sorts
+func SqlKeyset(sorts []Sort, values []any, columns map[string]string) (string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	for i, sort := range sorts {
+		parts := []string{}
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[sorts[j].Field]+" = ?")
+			args = append(args, values[j])
+		}
+
+		operator := ">"
+		if sort.Order == SortDesc {
+			operator = "<"
+		}
+
+		parts = append(parts, columns[sort.Field]+" "+operator+" ?")
+		args = append(args, values[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// SqlOrderBy returns the ORDER BY expression of sorts
+func SqlOrderBy(sorts []Sort, columns map[string]string) string {
+	expressions := []string{}
+	for _, sort := range sorts {
+		expressions = append(expressions, columns[sort.Field]+" "+strings.ToUpper(string(sort.Order)))
+	}
+
+	return strings.Join(expressions, ", ")
+}
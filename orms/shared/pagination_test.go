@@ -0,0 +1,167 @@
+package shared
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type listedItem struct {
+	Id        string    `json:"id"`
+	Name      *string   `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var listedItemFields = map[string]string{"id": "id", "name": "name", "createdAt": "created_at"}
+
+// compareValues compares the value of a field with the one of a cursor, which
+// must have the type of the field as drivers compare the values as they're bound
+func compareValues(t *testing.T, value any, cursorValue any) int {
+	switch value := value.(type) {
+	case time.Time:
+		at, ok := cursorValue.(time.Time)
+		if !ok {
+			t.Fatalf("cursor value %#v isn't a time", cursorValue)
+		}
+
+		return value.Compare(at)
+	case string:
+		s, ok := cursorValue.(string)
+		if !ok {
+			t.Fatalf("cursor value %#v isn't a string", cursorValue)
+		}
+
+		return strings.Compare(value, s)
+	default:
+		t.Fatalf("can't compare %#v", value)
+		return 0
+	}
+}
+
+// listItems lists items like the keyset queries of the daos, by comparing the
+// sort fields with the decoded cursor values
+func listItems(t *testing.T, items []listedItem, params ListParams) *Page[listedItem] {
+	normalizedParams, err := params.Normalize(listedItem{}, listedItemFields, []string{"id", "createdAt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params = *normalizedParams
+
+	compare := func(a listedItem, b []any) int {
+		for i, sort := range params.Sort {
+			value, err := fieldValue(a, sort.Field)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := compareValues(t, value, b[i])
+			if sort.Order == SortDesc {
+				result = -result
+			}
+
+			if result != 0 {
+				return result
+			}
+		}
+
+		return 0
+	}
+
+	values := func(item listedItem) []any {
+		values := []any{}
+		for _, sort := range params.Sort {
+			value, err := fieldValue(item, sort.Field)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			values = append(values, value)
+		}
+
+		return values
+	}
+
+	listed := slices.Clone(items)
+	slices.SortFunc(listed, func(a, b listedItem) int { return compare(a, values(b)) })
+
+	if params.Cursor != "" {
+		cursorValues, err := DecodeCursor(listedItem{}, params.Cursor, params.Sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		listed = slices.DeleteFunc(listed, func(item listedItem) bool { return compare(item, cursorValues) <= 0 })
+	}
+
+	listed = listed[:min(len(listed), params.Limit+1)]
+
+	page, err := NewPage(listed, int64(len(items)), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return page
+}
+
+func TestCursorPagesThroughTimestampSort(t *testing.T) {
+	createdAt := time.Date(2026, 10, 19, 6, 49, 13, 222955038, time.UTC)
+	items := []listedItem{
+		{Id: "a", CreatedAt: createdAt.Add(time.Second)},
+		{Id: "b", CreatedAt: createdAt},
+		{Id: "c", CreatedAt: createdAt.Add(time.Nanosecond)},
+		{Id: "d", CreatedAt: createdAt},
+		{Id: "e", CreatedAt: createdAt.Add(-time.Hour)},
+	}
+
+	for order, expected := range map[SortOrder][]string{
+		SortAsc:  {"e", "b", "d", "c", "a"},
+		SortDesc: {"a", "c", "b", "d", "e"},
+	} {
+		params := ListParams{Limit: 2, Sort: []Sort{{Field: "createdAt", Order: order}}}
+		listed := []string{}
+
+		for range items {
+			page := listItems(t, items, params)
+			for _, item := range page.Items {
+				listed = append(listed, item.Id)
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+
+			params.Cursor = page.NextCursor
+		}
+
+		if !slices.Equal(listed, expected) {
+			t.Errorf("listed %v sorted by createdAt %s, expected %v", listed, order, expected)
+		}
+	}
+}
+
+func TestNormalizeDecodesFilterValues(t *testing.T) {
+	params := ListParams{Filters: []Filter{{Field: "createdAt", Operator: FilterEquals, Value: "2026-10-19T06:49:13.222955038Z"}}}
+
+	normalizedParams, err := params.Normalize(listedItem{}, listedItemFields, []string{"id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at, ok := normalizedParams.Filters[0].Value.(time.Time)
+	if !ok || !at.Equal(time.Date(2026, 10, 19, 6, 49, 13, 222955038, time.UTC)) {
+		t.Errorf("decoded the createdAt filter value to %#v", normalizedParams.Filters[0].Value)
+	}
+
+	for _, filter := range []Filter{
+		{Field: "createdAt", Operator: FilterEquals, Value: "yesterday"},
+		{Field: "createdAt", Operator: FilterContains, Value: "2026"},
+		{Field: "name", Operator: FilterEquals, Value: 1.0},
+	} {
+		_, err := ListParams{Filters: []Filter{filter}}.Normalize(listedItem{}, listedItemFields, []string{"id"})
+		if err == nil {
+			t.Errorf("normalized the invalid filter %+v", filter)
+		}
+	}
+}
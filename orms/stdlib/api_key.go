@@ -0,0 +1,163 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// ApiKey lets a machine client act on behalf of a user, within its scope
+type ApiKey struct {
+	Id     string `json:"id" db:"id"`
+	UserId string `json:"userId" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// Prefix identifies the api key, it's part of the key and can be shown
+	Prefix     string     `json:"prefix" db:"prefix"`
+	SecretHash string     `json:"-" db:"secret_hash"`
+	Scope      string     `json:"scope" db:"scope"`
+	ExpiresAt  *time.Time `json:"expiresAt" db:"expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" db:"last_used_at"`
+	Revoked    bool       `json:"revoked" db:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const apiKeyColumns = "id, user_id, name, prefix, secret_hash, scope, expires_at, last_used_at, revoked{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const apiKeyColumns = "id, user_id, name, prefix, secret_hash, scope, expires_at, last_used_at, revoked"
+
+func scanApiKey(row interface{ Scan(...any) error }) (*ApiKey, error) {
+	apiKey := ApiKey{}
+
+	// @alchemy replace err := row.Scan(&apiKey.Id, &apiKey.UserId, &apiKey.Name, &apiKey.Prefix, &apiKey.SecretHash, &apiKey.Scope, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.Revoked{{ if .Timestamps }}, &apiKey.CreatedAt, &apiKey.UpdatedAt{{ end }})
+	err := row.Scan(&apiKey.Id, &apiKey.UserId, &apiKey.Name, &apiKey.Prefix, &apiKey.SecretHash, &apiKey.Scope, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.Revoked)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &apiKey, nil
+}
+
+type IApiKeyDao interface {
+	Create(context.Context, ApiKeyCreatePayload) (*ApiKey, error)
+	Get(context.Context, string) (*ApiKey, error)
+	GetByPrefix(context.Context, string) (*ApiKey, error)
+	// ListByUser returns every api key of a user, including the revoked ones
+	ListByUser(context.Context, string) ([]ApiKey, error)
+	// Revoke returns ErrNotFound if the api key doesn't exist or is already
+	// revoked, so concurrent rotations of the same key can't both succeed
+	Revoke(context.Context, string) error
+	SetLastUsedAt(context.Context, string, time.Time) error
+}
+
+type ApiKeyDao struct {
+	client DBTX
+}
+
+type ApiKeyCreatePayload struct {
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scope      string
+	ExpiresAt  *time.Time
+}
+
+func (r *ApiKeyDao) Create(ctx context.Context, payload ApiKeyCreatePayload) (*ApiKey, error) {
+	apiKey := ApiKey{
+		Id:         uuid.NewString(),
+		UserId:     payload.UserId,
+		Name:       payload.Name,
+		Prefix:     payload.Prefix,
+		SecretHash: payload.SecretHash,
+		Scope:      payload.Scope,
+		ExpiresAt:  payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO api_keys (id, user_id, name, prefix, secret_hash, scope, expires_at, revoked{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO api_keys (id, user_id, name, prefix, secret_hash, scope, expires_at, revoked) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		// @alchemy replace apiKey.Id, apiKey.UserId, apiKey.Name, apiKey.Prefix, apiKey.SecretHash, apiKey.Scope, apiKey.ExpiresAt, apiKey.Revoked{{ if .Timestamps }}, apiKey.CreatedAt, apiKey.UpdatedAt{{ end }},
+		apiKey.Id, apiKey.UserId, apiKey.Name, apiKey.Prefix, apiKey.SecretHash, apiKey.Scope, apiKey.ExpiresAt, apiKey.Revoked,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &apiKey, nil
+}
+
+func (r *ApiKeyDao) Get(ctx context.Context, id string) (*ApiKey, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?"), id)
+
+	return scanApiKey(row)
+}
+
+func (r *ApiKeyDao) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = ?"), prefix)
+
+	return scanApiKey(row)
+}
+
+func (r *ApiKeyDao) ListByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	rows, err := txOrClient(ctx, r.client).QueryContext(ctx, rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = ? ORDER BY name"), userId)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	apiKeys := []ApiKey{}
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyDao) Revoke(ctx context.Context, id string) error {
+	// @alchemy replace result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE api_keys SET revoked = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE id = ? AND revoked = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, id, false)
+	result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE api_keys SET revoked = ?, updated_at = ? WHERE id = ? AND revoked = ?"), true, time.Now(), id, false)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ApiKeyDao) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE api_keys SET last_used_at = ? WHERE id = ?"), lastUsedAt, id)
+	return translateError(err)
+}
+
+func NewApiKeyDao(client DBTX) IApiKeyDao {
+	return &ApiKeyDao{client: client}
+}
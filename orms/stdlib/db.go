@@ -0,0 +1,76 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strconv"
+	"strings"
+
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	_ "github.com/jackc/pgx/v5/stdlib"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	_ "github.com/go-sql-driver/mysql"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	_ "modernc.org/sqlite"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	_ "github.com/microsoft/go-mssqldb"
+	// @alchemy block {{- end }}
+)
+
+// @alchemy replace const driverName = {{ if eq .DatabaseProvider "mysql" }}"mysql"{{ else if eq .DatabaseProvider "sqlite" }}"sqlite"{{ else if eq .DatabaseProvider "sqlserver" }}"sqlserver"{{ else }}"pgx"{{ end }}
+const driverName = "pgx"
+
+// @alchemy replace const placeholder = {{ if or (eq .DatabaseProvider "mysql") (eq .DatabaseProvider "sqlite") }}"?"{{ else if eq .DatabaseProvider "sqlserver" }}"@p"{{ else }}"$"{{ end }}
+const placeholder = "$"
+
+// sqlserver pages with OFFSET ... FETCH instead of LIMIT ... OFFSET
+// @alchemy replace const offsetFetch = {{ if eq .DatabaseProvider "sqlserver" }}true{{ else }}false{{ end }}
+const offsetFetch = false
+
+// DBTX is implemented by *sql.DB, *sql.Tx and *sqlx.DB, so the DAOs work with
+// plain database/sql or with sqlx.
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// NewDB opens a database connection from the DATABASE_URL environment variable
+func NewDB() (*sql.DB, error) {
+	return sql.Open(driverName, os.Getenv("DATABASE_URL"))
+}
+
+// rebind converts the `?` placeholders in query to the placeholder syntax of the database
+func rebind(query string) string {
+	if placeholder == "?" {
+		return query
+	}
+
+	var builder strings.Builder
+	position := 0
+	for _, char := range query {
+		if char == '?' {
+			position++
+			builder.WriteString(placeholder + strconv.Itoa(position))
+			continue
+		}
+
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+// limitOffset appends the limit and offset of a page to a query with `?` placeholders
+func limitOffset(query string, limit int, offset int) (string, []any) {
+	if offsetFetch {
+		return query + " OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", []any{offset, limit}
+	}
+
+	return query + " LIMIT ? OFFSET ?", []any{limit, offset}
+}
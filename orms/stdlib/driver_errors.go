@@ -0,0 +1,81 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	"strings"
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	"github.com/jackc/pgx/v5/pgconn"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	"github.com/go-sql-driver/mysql"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	mssql "github.com/microsoft/go-mssqldb"
+	// @alchemy block {{- end }}
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// translateError converts the errors of the database driver to the errors of
+// the dao package
+func translateError(err error) error {
+	if err == nil || IsTranslated(err) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	// @alchemy block {{- if eq .DatabaseProvider "postgresql" }}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case "22P02", "23502", "23503", "23514":
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "mysql" }}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case 1048, 1366, 1406, 1452, 3819:
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlite" }}
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	if strings.Contains(err.Error(), "constraint failed") {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if eq .DatabaseProvider "sqlserver" }}
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		switch mssqlErr.Number {
+		case 2601, 2627:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case 245, 515, 547, 8114:
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+	// @alchemy block {{- end }}
+
+	return err
+}
@@ -0,0 +1,131 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+)
+
+// LinkedAccount links the account of a user at an OAuth provider to a User
+type LinkedAccount struct {
+	Id             string `json:"id" db:"id"`
+	UserId         string `json:"userId" db:"user_id"`
+	Provider       string `json:"provider" db:"provider"`
+	ProviderUserId string `json:"providerUserId" db:"provider_user_id"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const linkedAccountColumns = "id, user_id, provider, provider_user_id{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const linkedAccountColumns = "id, user_id, provider, provider_user_id"
+
+func scanLinkedAccount(row interface{ Scan(...any) error }) (*LinkedAccount, error) {
+	linkedAccount := LinkedAccount{}
+
+	// @alchemy replace err := row.Scan(&linkedAccount.Id, &linkedAccount.UserId, &linkedAccount.Provider, &linkedAccount.ProviderUserId{{ if .Timestamps }}, &linkedAccount.CreatedAt, &linkedAccount.UpdatedAt{{ end }})
+	err := row.Scan(&linkedAccount.Id, &linkedAccount.UserId, &linkedAccount.Provider, &linkedAccount.ProviderUserId)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &linkedAccount, nil
+}
+
+type ILinkedAccountDao interface {
+	Create(context.Context, LinkedAccountCreatePayload) (*LinkedAccount, error)
+	// GetByProviderUserId returns the linked account of a user at a provider,
+	// e.g the subject of a google id token
+	GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error)
+	ListByUser(context.Context, string) ([]LinkedAccount, error)
+	Delete(context.Context, string) error
+}
+
+type LinkedAccountDao struct {
+	client DBTX
+}
+
+type LinkedAccountCreatePayload struct {
+	UserId         string
+	Provider       string
+	ProviderUserId string
+}
+
+func (l *LinkedAccountDao) Create(ctx context.Context, payload LinkedAccountCreatePayload) (*LinkedAccount, error) {
+	linkedAccount := LinkedAccount{
+		Id:             uuid.NewString(),
+		UserId:         payload.UserId,
+		Provider:       payload.Provider,
+		ProviderUserId: payload.ProviderUserId,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, l.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO linked_accounts (id, user_id, provider, provider_user_id{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO linked_accounts (id, user_id, provider, provider_user_id) VALUES (?, ?, ?, ?)"),
+		// @alchemy replace linkedAccount.Id, linkedAccount.UserId, linkedAccount.Provider, linkedAccount.ProviderUserId{{ if .Timestamps }}, linkedAccount.CreatedAt, linkedAccount.UpdatedAt{{ end }},
+		linkedAccount.Id, linkedAccount.UserId, linkedAccount.Provider, linkedAccount.ProviderUserId,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &linkedAccount, nil
+}
+
+func (l *LinkedAccountDao) GetByProviderUserId(ctx context.Context, provider string, providerUserId string) (*LinkedAccount, error) {
+	row := txOrClient(ctx, l.client).QueryRowContext(
+		ctx,
+		rebind("SELECT "+linkedAccountColumns+" FROM linked_accounts WHERE provider = ? AND provider_user_id = ?"),
+		provider,
+		providerUserId,
+	)
+
+	return scanLinkedAccount(row)
+}
+
+func (l *LinkedAccountDao) ListByUser(ctx context.Context, userId string) ([]LinkedAccount, error) {
+	rows, err := txOrClient(ctx, l.client).QueryContext(
+		ctx,
+		rebind("SELECT "+linkedAccountColumns+" FROM linked_accounts WHERE user_id = ? ORDER BY provider"),
+		userId,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	linkedAccounts := []LinkedAccount{}
+	for rows.Next() {
+		linkedAccount, err := scanLinkedAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		linkedAccounts = append(linkedAccounts, *linkedAccount)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return linkedAccounts, nil
+}
+
+func (l *LinkedAccountDao) Delete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, l.client).ExecContext(ctx, rebind("DELETE FROM linked_accounts WHERE id = ?"), id)
+	return translateError(err)
+}
+
+func NewLinkedAccountDao(client DBTX) ILinkedAccountDao {
+	return &LinkedAccountDao{client: client}
+}
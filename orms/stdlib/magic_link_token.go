@@ -0,0 +1,120 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type MagicLinkToken struct {
+	Id        string    `json:"id" db:"id"`
+	Email     string    `json:"email" db:"email"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+	Used      bool      `json:"used" db:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const magicLinkTokenColumns = "id, email, token_hash, expires_at, used{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const magicLinkTokenColumns = "id, email, token_hash, expires_at, used"
+
+func scanMagicLinkToken(row interface{ Scan(...any) error }) (*MagicLinkToken, error) {
+	magicLinkToken := MagicLinkToken{}
+
+	// @alchemy replace err := row.Scan(&magicLinkToken.Id, &magicLinkToken.Email, &magicLinkToken.TokenHash, &magicLinkToken.ExpiresAt, &magicLinkToken.Used{{ if .Timestamps }}, &magicLinkToken.CreatedAt, &magicLinkToken.UpdatedAt{{ end }})
+	err := row.Scan(&magicLinkToken.Id, &magicLinkToken.Email, &magicLinkToken.TokenHash, &magicLinkToken.ExpiresAt, &magicLinkToken.Used)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &magicLinkToken, nil
+}
+
+type IMagicLinkTokenDao interface {
+	Create(context.Context, MagicLinkTokenCreatePayload) (*MagicLinkToken, error)
+	GetByTokenHash(context.Context, string) (*MagicLinkToken, error)
+	// Use returns ErrNotFound if the magic link token doesn't exist or is
+	// already used, so concurrent logins with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfEmail marks every magic link token of an email as used
+	UseAllOfEmail(context.Context, string) error
+}
+
+type MagicLinkTokenDao struct {
+	client DBTX
+}
+
+type MagicLinkTokenCreatePayload struct {
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *MagicLinkTokenDao) Create(ctx context.Context, payload MagicLinkTokenCreatePayload) (*MagicLinkToken, error) {
+	magicLinkToken := MagicLinkToken{
+		Id:        uuid.NewString(),
+		Email:     payload.Email,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO magic_link_tokens (id, email, token_hash, expires_at, used{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO magic_link_tokens (id, email, token_hash, expires_at, used) VALUES (?, ?, ?, ?, ?)"),
+		// @alchemy replace magicLinkToken.Id, magicLinkToken.Email, magicLinkToken.TokenHash, magicLinkToken.ExpiresAt, magicLinkToken.Used{{ if .Timestamps }}, magicLinkToken.CreatedAt, magicLinkToken.UpdatedAt{{ end }},
+		magicLinkToken.Id, magicLinkToken.Email, magicLinkToken.TokenHash, magicLinkToken.ExpiresAt, magicLinkToken.Used,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &magicLinkToken, nil
+}
+
+func (r *MagicLinkTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*MagicLinkToken, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+magicLinkTokenColumns+" FROM magic_link_tokens WHERE token_hash = ?"), tokenHash)
+
+	return scanMagicLinkToken(row)
+}
+
+func (r *MagicLinkTokenDao) Use(ctx context.Context, id string) error {
+	// @alchemy replace result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE magic_link_tokens SET used = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE id = ? AND used = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, id, false)
+	result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE magic_link_tokens SET used = ?, updated_at = ? WHERE id = ? AND used = ?"), true, time.Now(), id, false)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MagicLinkTokenDao) UseAllOfEmail(ctx context.Context, email string) error {
+	// @alchemy replace _, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE magic_link_tokens SET used = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE email = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, email)
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE magic_link_tokens SET used = ?, updated_at = ? WHERE email = ?"), true, time.Now(), email)
+	return translateError(err)
+}
+
+func NewMagicLinkTokenDao(client DBTX) IMagicLinkTokenDao {
+	return &MagicLinkTokenDao{client: client}
+}
@@ -0,0 +1,120 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type PasswordResetToken struct {
+	Id        string    `json:"id" db:"id"`
+	UserId    string    `json:"userId" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+	Used      bool      `json:"used" db:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const passwordResetTokenColumns = "id, user_id, token_hash, expires_at, used{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const passwordResetTokenColumns = "id, user_id, token_hash, expires_at, used"
+
+func scanPasswordResetToken(row interface{ Scan(...any) error }) (*PasswordResetToken, error) {
+	passwordResetToken := PasswordResetToken{}
+
+	// @alchemy replace err := row.Scan(&passwordResetToken.Id, &passwordResetToken.UserId, &passwordResetToken.TokenHash, &passwordResetToken.ExpiresAt, &passwordResetToken.Used{{ if .Timestamps }}, &passwordResetToken.CreatedAt, &passwordResetToken.UpdatedAt{{ end }})
+	err := row.Scan(&passwordResetToken.Id, &passwordResetToken.UserId, &passwordResetToken.TokenHash, &passwordResetToken.ExpiresAt, &passwordResetToken.Used)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &passwordResetToken, nil
+}
+
+type IPasswordResetTokenDao interface {
+	Create(context.Context, PasswordResetTokenCreatePayload) (*PasswordResetToken, error)
+	GetByTokenHash(context.Context, string) (*PasswordResetToken, error)
+	// Use returns ErrNotFound if the password reset token doesn't exist or is
+	// already used, so concurrent resets with the same token can't both succeed
+	Use(context.Context, string) error
+	// UseAllOfUser marks every password reset token of a user as used
+	UseAllOfUser(context.Context, string) error
+}
+
+type PasswordResetTokenDao struct {
+	client DBTX
+}
+
+type PasswordResetTokenCreatePayload struct {
+	UserId    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetTokenDao) Create(ctx context.Context, payload PasswordResetTokenCreatePayload) (*PasswordResetToken, error) {
+	passwordResetToken := PasswordResetToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used) VALUES (?, ?, ?, ?, ?)"),
+		// @alchemy replace passwordResetToken.Id, passwordResetToken.UserId, passwordResetToken.TokenHash, passwordResetToken.ExpiresAt, passwordResetToken.Used{{ if .Timestamps }}, passwordResetToken.CreatedAt, passwordResetToken.UpdatedAt{{ end }},
+		passwordResetToken.Id, passwordResetToken.UserId, passwordResetToken.TokenHash, passwordResetToken.ExpiresAt, passwordResetToken.Used,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &passwordResetToken, nil
+}
+
+func (r *PasswordResetTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+passwordResetTokenColumns+" FROM password_reset_tokens WHERE token_hash = ?"), tokenHash)
+
+	return scanPasswordResetToken(row)
+}
+
+func (r *PasswordResetTokenDao) Use(ctx context.Context, id string) error {
+	// @alchemy replace result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE password_reset_tokens SET used = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE id = ? AND used = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, id, false)
+	result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE password_reset_tokens SET used = ?, updated_at = ? WHERE id = ? AND used = ?"), true, time.Now(), id, false)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PasswordResetTokenDao) UseAllOfUser(ctx context.Context, userId string) error {
+	// @alchemy replace _, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE password_reset_tokens SET used = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE user_id = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, userId)
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE password_reset_tokens SET used = ?, updated_at = ? WHERE user_id = ?"), true, time.Now(), userId)
+	return translateError(err)
+}
+
+func NewPasswordResetTokenDao(client DBTX) IPasswordResetTokenDao {
+	return &PasswordResetTokenDao{client: client}
+}
@@ -0,0 +1,98 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	// @alchemy block {{- if .Timestamps }}
+	"time"
+	// @alchemy block {{- end }}
+
+	"github.com/google/uuid"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+// RecoveryCode signs a user in once, in place of a code of their authenticator app
+type RecoveryCode struct {
+	Id       string `json:"id" db:"id"`
+	UserId   string `json:"userId" db:"user_id"`
+	CodeHash string `json:"-" db:"code_hash"`
+	Used     bool   `json:"used" db:"used"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+type IRecoveryCodeDao interface {
+	Create(context.Context, RecoveryCodeCreatePayload) (*RecoveryCode, error)
+	// Use marks the unused recovery code of a user with codeHash as used, it
+	// returns ErrNotFound if there is none, so a code can't be used twice
+	Use(ctx context.Context, userId string, codeHash string) error
+	// DeleteAllOfUser deletes the recovery codes of a user, e.g once new ones
+	// are generated
+	DeleteAllOfUser(context.Context, string) error
+}
+
+type RecoveryCodeDao struct {
+	client DBTX
+}
+
+type RecoveryCodeCreatePayload struct {
+	UserId   string
+	CodeHash string
+}
+
+func (r *RecoveryCodeDao) Create(ctx context.Context, payload RecoveryCodeCreatePayload) (*RecoveryCode, error) {
+	recoveryCode := RecoveryCode{
+		Id:       uuid.NewString(),
+		UserId:   payload.UserId,
+		CodeHash: payload.CodeHash,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO recovery_codes (id, user_id, code_hash, used{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO recovery_codes (id, user_id, code_hash, used) VALUES (?, ?, ?, ?)"),
+		// @alchemy replace recoveryCode.Id, recoveryCode.UserId, recoveryCode.CodeHash, recoveryCode.Used{{ if .Timestamps }}, recoveryCode.CreatedAt, recoveryCode.UpdatedAt{{ end }},
+		recoveryCode.Id, recoveryCode.UserId, recoveryCode.CodeHash, recoveryCode.Used,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &recoveryCode, nil
+}
+
+func (r *RecoveryCodeDao) Use(ctx context.Context, userId string, codeHash string) error {
+	// @alchemy replace result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE recovery_codes SET used = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE user_id = ? AND code_hash = ? AND used = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, userId, codeHash, false)
+	result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE recovery_codes SET used = ?, updated_at = ? WHERE user_id = ? AND code_hash = ? AND used = ?"), true, time.Now(), userId, codeHash, false)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("DELETE FROM recovery_codes WHERE user_id = ?"), userId)
+	return translateError(err)
+}
+
+func NewRecoveryCodeDao(client DBTX) IRecoveryCodeDao {
+	return &RecoveryCodeDao{client: client}
+}
@@ -0,0 +1,122 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type RefreshToken struct {
+	Id        string    `json:"id" db:"id"`
+	UserId    string    `json:"userId" db:"user_id"`
+	FamilyId  string    `json:"familyId" db:"family_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+	Revoked   bool      `json:"revoked" db:"revoked"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const refreshTokenColumns = "id, user_id, family_id, token_hash, expires_at, revoked{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const refreshTokenColumns = "id, user_id, family_id, token_hash, expires_at, revoked"
+
+func scanRefreshToken(row interface{ Scan(...any) error }) (*RefreshToken, error) {
+	refreshToken := RefreshToken{}
+
+	// @alchemy replace err := row.Scan(&refreshToken.Id, &refreshToken.UserId, &refreshToken.FamilyId, &refreshToken.TokenHash, &refreshToken.ExpiresAt, &refreshToken.Revoked{{ if .Timestamps }}, &refreshToken.CreatedAt, &refreshToken.UpdatedAt{{ end }})
+	err := row.Scan(&refreshToken.Id, &refreshToken.UserId, &refreshToken.FamilyId, &refreshToken.TokenHash, &refreshToken.ExpiresAt, &refreshToken.Revoked)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &refreshToken, nil
+}
+
+type IRefreshTokenDao interface {
+	Create(context.Context, RefreshTokenCreatePayload) (*RefreshToken, error)
+	GetByTokenHash(context.Context, string) (*RefreshToken, error)
+	// Revoke returns ErrNotFound if the refresh token doesn't exist or is already
+	// revoked, so concurrent rotations of the same token can't both succeed
+	Revoke(context.Context, string) error
+	RevokeFamily(context.Context, string) error
+}
+
+type RefreshTokenDao struct {
+	client DBTX
+}
+
+type RefreshTokenCreatePayload struct {
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *RefreshTokenDao) Create(ctx context.Context, payload RefreshTokenCreatePayload) (*RefreshToken, error) {
+	refreshToken := RefreshToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		FamilyId:  payload.FamilyId,
+		TokenHash: payload.TokenHash,
+		ExpiresAt: payload.ExpiresAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, revoked{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, revoked) VALUES (?, ?, ?, ?, ?, ?)"),
+		// @alchemy replace refreshToken.Id, refreshToken.UserId, refreshToken.FamilyId, refreshToken.TokenHash, refreshToken.ExpiresAt, refreshToken.Revoked{{ if .Timestamps }}, refreshToken.CreatedAt, refreshToken.UpdatedAt{{ end }},
+		refreshToken.Id, refreshToken.UserId, refreshToken.FamilyId, refreshToken.TokenHash, refreshToken.ExpiresAt, refreshToken.Revoked,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *RefreshTokenDao) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = ?"), tokenHash)
+
+	return scanRefreshToken(row)
+}
+
+func (r *RefreshTokenDao) Revoke(ctx context.Context, id string) error {
+	// @alchemy replace result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE refresh_tokens SET revoked = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE id = ? AND revoked = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, id, false)
+	result, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE refresh_tokens SET revoked = ?, updated_at = ? WHERE id = ? AND revoked = ?"), true, time.Now(), id, false)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenDao) RevokeFamily(ctx context.Context, familyId string) error {
+	// @alchemy replace _, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE refresh_tokens SET revoked = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE family_id = ?"), true{{ if .Timestamps }}, time.Now(){{ end }}, familyId)
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE refresh_tokens SET revoked = ?, updated_at = ? WHERE family_id = ?"), true, time.Now(), familyId)
+	return translateError(err)
+}
+
+func NewRefreshTokenDao(client DBTX) IRefreshTokenDao {
+	return &RefreshTokenDao{client: client}
+}
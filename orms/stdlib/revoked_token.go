@@ -0,0 +1,83 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken revokes the token with id Jti, or every token of UserId issued
+// before RevokedAt when Jti is nil
+type RevokedToken struct {
+	Id        string    `json:"id" db:"id"`
+	UserId    string    `json:"userId" db:"user_id"`
+	Jti       *string   `json:"jti" db:"jti"`
+	RevokedAt time.Time `json:"revokedAt" db:"revoked_at"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+type IRevokedTokenDao interface {
+	Create(context.Context, RevokedTokenCreatePayload) (*RevokedToken, error)
+	// IsRevoked reports whether the token with id jti is revoked, or the tokens
+	// of userId were revoked after issuedAt
+	IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error)
+	// DeleteExpired deletes the revocations of tokens which expired anyway
+	DeleteExpired(context.Context) error
+}
+
+type RevokedTokenDao struct {
+	client DBTX
+}
+
+type RevokedTokenCreatePayload struct {
+	// Jti is nil to revoke every token of the user issued until now
+	Jti       *string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+func (r *RevokedTokenDao) Create(ctx context.Context, payload RevokedTokenCreatePayload) (*RevokedToken, error) {
+	revokedToken := RevokedToken{
+		Id:        uuid.NewString(),
+		UserId:    payload.UserId,
+		Jti:       payload.Jti,
+		RevokedAt: time.Now(),
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		rebind("INSERT INTO revoked_tokens (id, user_id, jti, revoked_at, expires_at) VALUES (?, ?, ?, ?, ?)"),
+		revokedToken.Id, revokedToken.UserId, revokedToken.Jti, revokedToken.RevokedAt, revokedToken.ExpiresAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &revokedToken, nil
+}
+
+func (r *RevokedTokenDao) IsRevoked(ctx context.Context, jti string, userId string, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := txOrClient(ctx, r.client).QueryRowContext(
+		ctx,
+		rebind("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ? OR (jti IS NULL AND user_id = ? AND revoked_at > ?)"),
+		jti, userId, issuedAt,
+	).Scan(&count)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RevokedTokenDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("DELETE FROM revoked_tokens WHERE expires_at < ?"), time.Now())
+	return translateError(err)
+}
+
+func NewRevokedTokenDao(client DBTX) IRevokedTokenDao {
+	return &RevokedTokenDao{client: client}
+}
@@ -0,0 +1,115 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session keeps a user logged in, the token of its cookie is stored hashed
+type Session struct {
+	Id         string    `json:"id" db:"id"`
+	UserId     string    `json:"userId" db:"user_id"`
+	TokenHash  string    `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time `json:"expiresAt" db:"expires_at"`
+	LastSeenAt time.Time `json:"lastSeenAt" db:"last_seen_at"`
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const sessionColumns = "id, user_id, token_hash, expires_at, last_seen_at{{ if .Timestamps }}, created_at, updated_at{{ end }}"
+const sessionColumns = "id, user_id, token_hash, expires_at, last_seen_at"
+
+func scanSession(row interface{ Scan(...any) error }) (*Session, error) {
+	session := Session{}
+
+	// @alchemy replace err := row.Scan(&session.Id, &session.UserId, &session.TokenHash, &session.ExpiresAt, &session.LastSeenAt{{ if .Timestamps }}, &session.CreatedAt, &session.UpdatedAt{{ end }})
+	err := row.Scan(&session.Id, &session.UserId, &session.TokenHash, &session.ExpiresAt, &session.LastSeenAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &session, nil
+}
+
+type ISessionDao interface {
+	Create(context.Context, SessionCreatePayload) (*Session, error)
+	GetByTokenHash(context.Context, string) (*Session, error)
+	SetLastSeenAt(context.Context, string, time.Time) error
+	Delete(context.Context, string) error
+	DeleteAllOfUser(context.Context, string) error
+	// DeleteExpired deletes the sessions past their absolute expiry
+	DeleteExpired(context.Context) error
+}
+
+type SessionDao struct {
+	client DBTX
+}
+
+type SessionCreatePayload struct {
+	UserId     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (r *SessionDao) Create(ctx context.Context, payload SessionCreatePayload) (*Session, error) {
+	session := Session{
+		Id:         uuid.NewString(),
+		UserId:     payload.UserId,
+		TokenHash:  payload.TokenHash,
+		ExpiresAt:  payload.ExpiresAt,
+		LastSeenAt: payload.LastSeenAt,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, r.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO sessions (id, user_id, token_hash, expires_at, last_seen_at{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO sessions (id, user_id, token_hash, expires_at, last_seen_at) VALUES (?, ?, ?, ?, ?)"),
+		// @alchemy replace session.Id, session.UserId, session.TokenHash, session.ExpiresAt, session.LastSeenAt{{ if .Timestamps }}, session.CreatedAt, session.UpdatedAt{{ end }},
+		session.Id, session.UserId, session.TokenHash, session.ExpiresAt, session.LastSeenAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &session, nil
+}
+
+func (r *SessionDao) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	row := txOrClient(ctx, r.client).QueryRowContext(ctx, rebind("SELECT "+sessionColumns+" FROM sessions WHERE token_hash = ?"), tokenHash)
+
+	return scanSession(row)
+}
+
+func (r *SessionDao) SetLastSeenAt(ctx context.Context, id string, lastSeenAt time.Time) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("UPDATE sessions SET last_seen_at = ? WHERE id = ?"), lastSeenAt, id)
+	return translateError(err)
+}
+
+func (r *SessionDao) Delete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("DELETE FROM sessions WHERE id = ?"), id)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteAllOfUser(ctx context.Context, userId string) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("DELETE FROM sessions WHERE user_id = ?"), userId)
+	return translateError(err)
+}
+
+func (r *SessionDao) DeleteExpired(ctx context.Context) error {
+	_, err := txOrClient(ctx, r.client).ExecContext(ctx, rebind("DELETE FROM sessions WHERE expires_at < ?"), time.Now())
+	return translateError(err)
+}
+
+func NewSessionDao(client DBTX) ISessionDao {
+	return &SessionDao{client: client}
+}
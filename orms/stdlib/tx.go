@@ -0,0 +1,62 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type txKey struct{}
+
+// TxBeginner is implemented by *sql.DB and *sqlx.DB
+type TxBeginner interface {
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+}
+
+type ITxManager interface {
+	// WithinTx runs fn in a transaction, the DAOs called with the context passed
+	// to fn use the transaction. The transaction is rolled back if fn returns an
+	// error, nested calls join the outer transaction.
+	WithinTx(context.Context, func(context.Context) error) error
+}
+
+type TxManager struct {
+	client TxBeginner
+}
+
+func (t *TxManager) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// txOrClient returns the transaction of ctx, or client if ctx has none
+func txOrClient(ctx context.Context, client DBTX) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return client
+}
+
+func NewTxManager(client TxBeginner) ITxManager {
+	return &TxManager{client: client}
+}
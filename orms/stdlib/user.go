@@ -0,0 +1,355 @@
+// @alchemy replace package dao
+package stdlib
+
+import (
+	"context"
+	"strings"
+	// @alchemy block {{- if or .Timestamps .SoftDelete .EmailVerification }}
+	"time"
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	"github.com/google/uuid"
+	// @alchemy block {{- end }}
+
+	// @alchemy replace
+	. "github.com/struckchure/go-alchemy/orms/shared"
+)
+
+type User struct {
+	Id        string  `json:"id" db:"id"`
+	FirstName *string `json:"firstName" db:"first_name"`
+	LastName  *string `json:"lastName" db:"last_name"`
+	Email     string  `json:"email" db:"email"`
+	Password  string  `json:"-" db:"password"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt" db:"email_verified_at"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"-" db:"mfa_secret"`
+	MfaEnabled bool    `json:"mfaEnabled" db:"mfa_enabled"`
+	// MfaTotpStep is the time step of the last code accepted, so a code can't
+	// be replayed
+	MfaTotpStep int64 `json:"-" db:"mfa_totp_step"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Timestamps }}
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .SoftDelete }}
+	DeletedAt *time.Time `json:"deletedAt" db:"deleted_at"`
+	// @alchemy block {{- end }}
+}
+
+// @alchemy replace const userColumns = "id, first_name, last_name, email, password{{ if .EmailVerification }}, email_verified_at{{ end }}{{ if .MFA }}, mfa_secret, mfa_enabled, mfa_totp_step{{ end }}{{ if .Timestamps }}, created_at, updated_at{{ end }}{{ if .SoftDelete }}, deleted_at{{ end }}"
+const userColumns = "id, first_name, last_name, email, password"
+
+// userFields are the filterable fields of User
+var userFields map[string]string = map[string]string{
+	"id":        "id",
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	// @alchemy block {{- end }}
+}
+
+// userSortFields are the sortable fields of User, the nullable names can only
+// be filtered by
+var userSortFields []string = []string{
+	"id",
+	"email",
+	// @alchemy block {{- if .Timestamps }}
+	"createdAt",
+	"updatedAt",
+	// @alchemy block {{- end }}
+}
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	user := User{}
+
+	// @alchemy replace err := row.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.Password{{ if .EmailVerification }}, &user.EmailVerifiedAt{{ end }}{{ if .MFA }}, &user.MfaSecret, &user.MfaEnabled, &user.MfaTotpStep{{ end }}{{ if .Timestamps }}, &user.CreatedAt, &user.UpdatedAt{{ end }}{{ if .SoftDelete }}, &user.DeletedAt{{ end }})
+	err := row.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.Password)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+type IUserDao interface {
+	List(context.Context, ListParams) (*Page[User], error)
+	Get(context.Context, string) (*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+	Create(context.Context, UserCreatePayload) (*User, error)
+	// @alchemy block {{- end }}
+	Update(context.Context, string, UserUpdatePayload) (*User, error)
+	// @alchemy block {{- if .MFA }}
+	// UseMfaTotpStep records the time step of an accepted code, it returns
+	// ErrNotFound when a code of the step or a later one was accepted already
+	UseMfaTotpStep(context.Context, string, int64) error
+	// @alchemy block {{- end }}
+	Delete(context.Context, string) error
+	// @alchemy block {{- if .SoftDelete }}
+	Restore(context.Context, string) error
+	HardDelete(context.Context, string) error
+	// @alchemy block {{- end }}
+}
+
+type UserDao struct {
+	client DBTX
+}
+
+func (u *UserDao) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	normalizedParams, err := params.Normalize(User{}, userFields, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	params = *normalizedParams
+
+	conditions := []string{}
+	args := []any{}
+
+	// @alchemy block {{ if .SoftDelete }}
+	if !params.WithDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	// @alchemy block {{- end }}
+
+	condition, filterArgs := SqlFilters(params.Filters, userFields)
+	if condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, filterArgs...)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	err = txOrClient(ctx, u.client).QueryRowContext(ctx, rebind("SELECT COUNT(*) FROM users"+where), args...).Scan(&total)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	offset := params.Offset
+	if params.Cursor != "" {
+		values, err := DecodeCursor(User{}, params.Cursor, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		condition, keysetArgs := SqlKeyset(params.Sort, values, userFields)
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+		offset = 0
+	}
+
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query, pageArgs := limitOffset(
+		"SELECT "+userColumns+" FROM users"+where+" ORDER BY "+SqlOrderBy(params.Sort, userFields),
+		params.Limit+1,
+		offset,
+	)
+
+	rows, err := txOrClient(ctx, u.client).QueryContext(ctx, rebind(query), append(args, pageArgs...)...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return NewPage(users, total, params)
+}
+
+func (u *UserDao) Get(ctx context.Context, id string) (*User, error) {
+	// @alchemy replace row := txOrClient(ctx, u.client).QueryRowContext(ctx, rebind("SELECT "+userColumns+" FROM users WHERE id = ?{{ if .SoftDelete }} AND deleted_at IS NULL{{ end }}"), id)
+	row := txOrClient(ctx, u.client).QueryRowContext(ctx, rebind("SELECT "+userColumns+" FROM users WHERE id = ?"), id)
+
+	return scanUser(row)
+}
+
+func (u *UserDao) GetByEmail(ctx context.Context, email string) (*User, error) {
+	// @alchemy replace row := txOrClient(ctx, u.client).QueryRowContext(ctx, rebind("SELECT "+userColumns+" FROM users WHERE email = ?{{ if .SoftDelete }} AND deleted_at IS NULL{{ end }}"), email)
+	row := txOrClient(ctx, u.client).QueryRowContext(ctx, rebind("SELECT "+userColumns+" FROM users WHERE email = ?"), email)
+
+	return scanUser(row)
+}
+
+// @alchemy block {{- if or .Register .OAuth .MagicLink }}
+
+type UserCreatePayload struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Email     string  `json:"email,omitempty"`
+	Password  string  `json:"password,omitempty"`
+}
+
+func (u *UserDao) Create(ctx context.Context, payload UserCreatePayload) (*User, error) {
+	user := User{
+		Id:        uuid.NewString(),
+		FirstName: payload.FirstName,
+		LastName:  payload.LastName,
+		Email:     payload.Email,
+		Password:  payload.Password,
+		// @alchemy block {{- if .Timestamps }}
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		// @alchemy block {{- end }}
+	}
+
+	_, err := txOrClient(ctx, u.client).ExecContext(
+		ctx,
+		// @alchemy replace rebind("INSERT INTO users (id, first_name, last_name, email, password{{ if .Timestamps }}, created_at, updated_at{{ end }}) VALUES (?, ?, ?, ?, ?{{ if .Timestamps }}, ?, ?{{ end }})"),
+		rebind("INSERT INTO users (id, first_name, last_name, email, password) VALUES (?, ?, ?, ?, ?)"),
+		// @alchemy replace user.Id, user.FirstName, user.LastName, user.Email, user.Password{{ if .Timestamps }}, user.CreatedAt, user.UpdatedAt{{ end }},
+		user.Id, user.FirstName, user.LastName, user.Email, user.Password,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+// @alchemy block {{- end }}
+
+type UserUpdatePayload struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Email     *string `json:"email,omitempty"`
+	Password  *string `json:"password,omitempty"`
+	// @alchemy block {{- if .EmailVerification }}
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	MfaSecret  *string `json:"mfaSecret,omitempty"`
+	MfaEnabled *bool   `json:"mfaEnabled,omitempty"`
+	// @alchemy block {{- end }}
+}
+
+func (u *UserDao) Update(ctx context.Context, id string, payload UserUpdatePayload) (*User, error) {
+	columns := []struct {
+		name  string
+		value *string
+	}{
+		{"first_name", payload.FirstName},
+		{"last_name", payload.LastName},
+		{"email", payload.Email},
+		{"password", payload.Password},
+		// @alchemy block {{- if .MFA }}
+		{"mfa_secret", payload.MfaSecret},
+		// @alchemy block {{- end }}
+	}
+
+	sets := []string{}
+	args := []any{}
+	for _, column := range columns {
+		if column.value != nil {
+			sets = append(sets, column.name+" = ?")
+			args = append(args, *column.value)
+		}
+	}
+
+	// @alchemy block {{- if .EmailVerification }}
+
+	if payload.EmailVerifiedAt != nil {
+		sets = append(sets, "email_verified_at = ?")
+		args = append(args, *payload.EmailVerifiedAt)
+	}
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+
+	if payload.MfaEnabled != nil {
+		sets = append(sets, "mfa_enabled = ?")
+		args = append(args, *payload.MfaEnabled)
+	}
+	// @alchemy block {{- end }}
+
+	if len(sets) > 0 {
+		// @alchemy block {{- if .Timestamps }}
+		sets = append(sets, "updated_at = ?")
+		args = append(args, time.Now())
+
+		// @alchemy block {{ end }}
+		// @alchemy replace query := "UPDATE users SET " + strings.Join(sets, ", ") + " WHERE id = ?{{ if .SoftDelete }} AND deleted_at IS NULL{{ end }}"
+		query := "UPDATE users SET " + strings.Join(sets, ", ") + " WHERE id = ?"
+		_, err := txOrClient(ctx, u.client).ExecContext(ctx, rebind(query), append(args, id)...)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	return u.Get(ctx, id)
+}
+
+// @alchemy block {{- if .MFA }}
+
+func (u *UserDao) UseMfaTotpStep(ctx context.Context, id string, step int64) error {
+	// @alchemy replace result, err := txOrClient(ctx, u.client).ExecContext(ctx, rebind("UPDATE users SET mfa_totp_step = ?{{ if .Timestamps }}, updated_at = ?{{ end }} WHERE id = ? AND mfa_totp_step < ?"), step{{ if .Timestamps }}, time.Now(){{ end }}, id, step)
+	result, err := txOrClient(ctx, u.client).ExecContext(ctx, rebind("UPDATE users SET mfa_totp_step = ?, updated_at = ? WHERE id = ? AND mfa_totp_step < ?"), step, time.Now(), id, step)
+	if err != nil {
+		return translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// @alchemy block {{- end }}
+
+func (u *UserDao) Delete(ctx context.Context, id string) error {
+	var err error
+	// @alchemy block {{- if .SoftDelete }}
+	_, err = txOrClient(ctx, u.client).ExecContext(ctx, rebind("UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL"), time.Now(), id)
+	// @alchemy block {{- else }}
+	_, err = txOrClient(ctx, u.client).ExecContext(ctx, rebind("DELETE FROM users WHERE id = ?"), id)
+	// @alchemy block {{- end }}
+	return translateError(err)
+}
+
+// @alchemy block {{- if .SoftDelete }}
+func (u *UserDao) Restore(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, u.client).ExecContext(ctx, rebind("UPDATE users SET deleted_at = NULL WHERE id = ?"), id)
+	return translateError(err)
+}
+
+func (u *UserDao) HardDelete(ctx context.Context, id string) error {
+	_, err := txOrClient(ctx, u.client).ExecContext(ctx, rebind("DELETE FROM users WHERE id = ?"), id)
+	return translateError(err)
+}
+
+// @alchemy block {{- end }}
+
+func NewUserDao(client DBTX) IUserDao {
+	return &UserDao{client: client}
+}
@@ -0,0 +1,75 @@
+package seeds
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	// @alchemy statement "{{ .ModuleName }}/dao"
+	dao "github.com/struckchure/go-alchemy/orms/gorm"
+	// @alchemy statement "{{ .ModuleName }}/services"
+	"github.com/struckchure/go-alchemy/services"
+)
+
+var firstNames []string = []string{
+	"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken",
+	"Frances", "Donald", "Radia", "Edsger", "Katherine", "John", "Hedy", "Tim",
+}
+
+var lastNames []string = []string{
+	"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson",
+	"Allen", "Knuth", "Perlman", "Dijkstra", "Johnson", "McCarthy", "Lamarr", "Berners-Lee",
+}
+
+// UserPassword is the password of every seeded user
+const UserPassword string = "password"
+
+// SeedUsers creates count users, the same rng seed always creates the same users
+func SeedUsers(ctx context.Context, userDao dao.IUserDao, passwordHasher services.IPasswordHasher, rng *rand.Rand, count int) error {
+	// hashing is slow on purpose, so every user shares the same hash
+	hashedPassword, err := passwordHasher.Hash(ctx, UserPassword)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= count; i++ {
+		firstName := firstNames[rng.Intn(len(firstNames))]
+		lastName := lastNames[rng.Intn(len(lastNames))]
+
+		_, err := userDao.Create(ctx, dao.UserCreatePayload{
+			FirstName: &firstName,
+			LastName:  &lastName,
+			Email:     strings.ToLower(fmt.Sprintf("%s.%s%d@example.com", firstName, lastName, i)),
+			Password:  hashedPassword,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed user %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ResetUsers deletes every user
+func ResetUsers(ctx context.Context, userDao dao.IUserDao) error {
+	for {
+		// @alchemy replace {{ if .SoftDelete }}page, err := userDao.List(ctx, dao.ListParams{Limit: dao.MaxListLimit, WithDeleted: true}){{ else }}page, err := userDao.List(ctx, dao.ListParams{Limit: dao.MaxListLimit}){{ end }}
+		page, err := userDao.List(ctx, dao.ListParams{Limit: dao.MaxListLimit})
+		if err != nil {
+			return err
+		}
+
+		if len(page.Items) == 0 {
+			return nil
+		}
+
+		for _, user := range page.Items {
+			// @alchemy replace {{ if .SoftDelete }}err := userDao.HardDelete(ctx, user.Id){{ else }}err := userDao.Delete(ctx, user.Id){{ end }}
+			err := userDao.Delete(ctx, user.Id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -1,49 +1,172 @@
 package services
 
 import (
+	"context"
+	// @alchemy block {{- if .APIKeys }}
+	"crypto/subtle"
+	// @alchemy block {{- end }}
 	"errors"
+	// @alchemy block {{- if or .PasswordReset .EmailVerification .OAuth .MagicLink .APIKeys }}
+	"fmt"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Login }}
+	"log"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if or .MagicLink .APIKeys }}
+	"strings"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Login }}
+	"sync"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if or .PasswordReset .EmailVerification .OAuth .MFA .MagicLink .APIKeys .Sessions }}
+	"time"
+	// @alchemy block {{- end }}
 
+	// @alchemy block {{- if .APIKeys }}
+	"github.com/golang-jwt/jwt/v5"
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .OAuth }}
+	"github.com/samber/lo"
+	// @alchemy block {{- end }}
 	// @alchemy statement "{{ .ModuleName }}/dao"
 	"github.com/struckchure/go-alchemy/orms/prisma"
-	// @alchemy statement "{{ .ModuleName }}/prisma/db"
-	"github.com/struckchure/go-alchemy/prisma/db"
+	// @alchemy replace
+	"github.com/struckchure/go-alchemy/orms/shared"
 )
 
 type IAuthenticationService interface {
 	// @alchemy block {{- if .Login }}
-	Login(LoginArgs) (*LoginResult, error)
+	Login(context.Context, LoginArgs) (*LoginResult, error)
 	// @alchemy block {{- end }}
 	// @alchemy block {{- if .Register }}
-	Register(RegisterArgs) (*RegisterResult, error)
+	Register(context.Context, RegisterArgs) (*RegisterResult, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Refresh }}
+	Refresh(context.Context, RefreshArgs) (*RefreshResult, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Logout }}
+	Logout(context.Context, LogoutArgs) error
+	LogoutEverywhere(context.Context, LogoutEverywhereArgs) error
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .PasswordReset }}
+	RequestPasswordReset(context.Context, RequestPasswordResetArgs) error
+	ResetPassword(context.Context, ResetPasswordArgs) error
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .EmailVerification }}
+	VerifyEmail(context.Context, VerifyEmailArgs) error
+	ResendVerification(context.Context, ResendVerificationArgs) error
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .OAuth }}
+	BeginOAuth(context.Context, BeginOAuthArgs) (*BeginOAuthResult, error)
+	CompleteOAuth(context.Context, CompleteOAuthArgs) (*CompleteOAuthResult, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	EnrollMFA(context.Context, EnrollMFAArgs) (*EnrollMFAResult, error)
+	ConfirmMFA(context.Context, ConfirmMFAArgs) (*RecoveryCodesResult, error)
+	VerifyMFA(context.Context, VerifyMFAArgs) (*LoginResult, error)
+	RegenerateRecoveryCodes(context.Context, RegenerateRecoveryCodesArgs) (*RecoveryCodesResult, error)
+	DisableMFA(context.Context, DisableMFAArgs) error
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MagicLink }}
+	RequestMagicLink(context.Context, RequestMagicLinkArgs) error
+	RedeemMagicLink(context.Context, RedeemMagicLinkArgs) (*RedeemMagicLinkResult, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .APIKeys }}
+	CreateAPIKey(context.Context, CreateAPIKeyArgs) (*CreateAPIKeyResult, error)
+	ListAPIKeys(context.Context, ListAPIKeysArgs) (*ListAPIKeysResult, error)
+	RotateAPIKey(context.Context, RotateAPIKeyArgs) (*CreateAPIKeyResult, error)
+	RevokeAPIKey(context.Context, RevokeAPIKeyArgs) error
+	// ValidateAPIKey returns the claims of an api key, shaped like the claims of
+	// an access token
+	ValidateAPIKey(context.Context, string) (*Claims, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Sessions }}
+	// ValidateSession returns the session of a session token, the token comes
+	// from the session cookie
+	ValidateSession(context.Context, string) (*Session, error)
+	EndSession(context.Context, EndSessionArgs) error
+	EndAllSessions(context.Context, EndAllSessionsArgs) error
 	// @alchemy block {{- end }}
 }
 
 type AuthenticationService struct {
 	// @alchemy replace userDao dao.IUserDao
-	userDao    prisma.IUserDao
-	jwtService IJwtService
-}
-
-func (a *AuthenticationService) passwordIsValid(plain string, hashed string) (bool, error) {
-	return plain == hashed, nil
+	userDao        prisma.IUserDao
+	jwtService     IJwtService
+	passwordHasher IPasswordHasher
+	// @alchemy block {{- if .Refresh }}
+	// @alchemy replace refreshTokenDao dao.IRefreshTokenDao
+	refreshTokenDao prisma.IRefreshTokenDao
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .PasswordReset }}
+	// @alchemy replace passwordResetTokenDao dao.IPasswordResetTokenDao
+	passwordResetTokenDao prisma.IPasswordResetTokenDao
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if or .PasswordReset .EmailVerification .MagicLink }}
+	mailer IMailer
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .OAuth }}
+	// @alchemy replace linkedAccountDao dao.ILinkedAccountDao
+	linkedAccountDao prisma.ILinkedAccountDao
+	oauthStateStore  IOAuthStateStore
+	oauthProviders   map[string]IOAuthProvider
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	// @alchemy replace recoveryCodeDao dao.IRecoveryCodeDao
+	recoveryCodeDao prisma.IRecoveryCodeDao
+	mfaRateLimiter  IRateLimiter
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MagicLink }}
+	// @alchemy replace magicLinkTokenDao dao.IMagicLinkTokenDao
+	magicLinkTokenDao    prisma.IMagicLinkTokenDao
+	magicLinkRateLimiter IRateLimiter
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .APIKeys }}
+	// @alchemy replace apiKeyDao dao.IApiKeyDao
+	apiKeyDao prisma.IApiKeyDao
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Sessions }}
+	sessionStore ISessionStore
+	// @alchemy block {{- end }}
 }
 
 // @alchemy block {{- if .Login  }}
+
 type LoginArgs struct {
 	Email    string
 	Password string
+	// @alchemy block {{- if .Sessions }}
+	// Session starts a cookie session instead of returning tokens
+	Session bool
+	// SessionToken is the token of the session cookie the login request came
+	// with, if any. That session ends, so the session is rotated on each login.
+	SessionToken string
+	// @alchemy block {{- end }}
 }
 
 type LoginResult struct {
 	// @alchemy replace User dao.User `json:"user"`
-	User   prisma.User `json:"user"`
-	Tokens Tokens      `json:"tokens"`
+	User prisma.User `json:"user"`
+	// @alchemy replace Tokens {{ if or .MFA .Sessions }}*Tokens `json:"tokens,omitempty"`{{ else }}Tokens `json:"tokens"`{{ end }}
+	Tokens Tokens `json:"tokens"`
+	// @alchemy block {{- if .Sessions }}
+	// Session is returned instead of Tokens when the login asked for a session
+	Session *SessionResult `json:"session,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	// MFAToken is returned instead of Tokens when the user enabled MFA, VerifyMFA
+	// completes the login with it
+	MFAToken string `json:"mfaToken,omitempty"`
+	// @alchemy block {{- end }}
 }
 
-func (a *AuthenticationService) Login(args LoginArgs) (*LoginResult, error) {
-	user, err := a.userDao.GetByEmail(args.Email)
+func (a *AuthenticationService) Login(ctx context.Context, args LoginArgs) (*LoginResult, error) {
+	user, err := a.userDao.GetByEmail(ctx, args.Email)
 	if err != nil {
-		if errors.Is(db.ErrNotFound, err) {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			a.verifyDummyPassword(ctx, args.Password)
+
 			return nil, errors.New("invalid credentials")
 		}
 
@@ -51,10 +174,12 @@ func (a *AuthenticationService) Login(args LoginArgs) (*LoginResult, error) {
 	}
 
 	if user == nil {
+		a.verifyDummyPassword(ctx, args.Password)
+
 		return nil, errors.New("invalid credentials")
 	}
 
-	passwordIsValid, err := a.passwordIsValid(args.Password, user.Password)
+	passwordIsValid, err := a.passwordHasher.Verify(ctx, args.Password, user.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -63,14 +188,90 @@ func (a *AuthenticationService) Login(args LoginArgs) (*LoginResult, error) {
 		return nil, errors.New("invalid credentials")
 	}
 
-	tokens, err := a.jwtService.GenerateTokens(Claims{Sub: user.Id})
+	// @alchemy block {{- if .EmailVerification }}
+
+	if EMAIL_VERIFICATION_REQUIRED == "true" && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	// @alchemy block {{- end }}
+
+	// the password is valid, so a failed rehash is retried on the next login
+	err = a.rehashPassword(ctx, user.Id, args.Password, user.Password)
+	if err != nil {
+		log.Printf("rehashing the password of user %s: %v", user.Id, err)
+	}
+
+	// @alchemy block {{- if .MFA }}
+
+	if user.MfaEnabled {
+		mfaToken, err := a.jwtService.GenerateMFAToken(ctx, Claims{Sub: user.Id})
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{User: *user, MFAToken: *mfaToken}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy block {{- if .Sessions }}
+
+	if args.Session {
+		session, err := a.startSession(ctx, user.Id, args.SessionToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{User: *user, Session: session}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy replace tokens, err := a.{{ if .Refresh }}startTokenFamily(ctx, user.Id){{ else }}jwtService.GenerateTokens(ctx, Claims{Sub: user.Id}){{ end }}
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: user.Id})
 	if err != nil {
 		return nil, err
 	}
 
+	// @alchemy replace return &LoginResult{User: *user, Tokens: {{ if not (or .MFA .Sessions) }}*{{ end }}tokens}, nil
 	return &LoginResult{User: *user, Tokens: *tokens}, nil
 }
 
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// verifyDummyPassword verifies password against a fixed hash when no user has
+// the email, so an unknown email takes as long to reject as a wrong password
+func (a *AuthenticationService) verifyDummyPassword(ctx context.Context, password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = a.passwordHasher.Hash(ctx, "dummy-password")
+	})
+
+	a.passwordHasher.Verify(ctx, password, dummyPasswordHash)
+}
+
+// rehashPassword replaces the hash of a user's password if it uses outdated
+// parameters, the password is only known while the user logs in
+func (a *AuthenticationService) rehashPassword(ctx context.Context, userId string, plain string, hashed string) error {
+	needsRehash, err := a.passwordHasher.NeedsRehash(ctx, hashed)
+	if err != nil || !needsRehash {
+		return err
+	}
+
+	newHash, err := a.passwordHasher.Hash(ctx, plain)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy replace _, err = a.userDao.Update(ctx, userId, dao.UserUpdatePayload{Password: &newHash})
+	_, err = a.userDao.Update(ctx, userId, prisma.UserUpdatePayload{Password: &newHash})
+
+	return err
+}
+
 // @alchemy block {{- end }}
 
 type RegisterArgs struct {
@@ -88,25 +289,47 @@ type RegisterResult struct {
 	Tokens Tokens      `json:"tokens"`
 }
 
-func (a *AuthenticationService) Register(args RegisterArgs) (*RegisterResult, error) {
+func (a *AuthenticationService) Register(ctx context.Context, args RegisterArgs) (*RegisterResult, error) {
+	hashedPassword, err := a.passwordHasher.Hash(ctx, args.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := a.userDao.Create(
+		ctx,
 		// @alchemy replace dao.UserCreatePayload{
 		prisma.UserCreatePayload{
 			FirstName: GetIfPresent(args.FirstName),
 			LastName:  GetIfPresent(args.LastName),
 			Email:     args.Email,
-			Password:  args.Password,
+			Password:  hashedPassword,
 		},
 	)
 	if err != nil {
-		if _, isUniqueConstraint := db.IsErrUniqueConstraint(err); isUniqueConstraint {
+		// @alchemy replace if errors.Is(err, dao.ErrConflict) {
+		if errors.Is(err, shared.ErrConflict) {
 			return nil, errors.New("user already exist")
 		}
 
 		return nil, err
 	}
 
-	tokens, err := a.jwtService.GenerateTokens(Claims{Sub: user.Id})
+	// @alchemy block {{- if .EmailVerification }}
+
+	err = a.sendVerificationEmail(ctx, *user)
+	if err != nil {
+		return nil, err
+	}
+
+	// users who have to verify their email before logging in get no tokens either
+	if EMAIL_VERIFICATION_REQUIRED == "true" {
+		return &RegisterResult{User: *user}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy replace tokens, err := a.{{ if .Refresh }}startTokenFamily(ctx, user.Id){{ else }}jwtService.GenerateTokens(ctx, Claims{Sub: user.Id}){{ end }}
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: user.Id})
 	if err != nil {
 		return nil, err
 	}
@@ -116,13 +339,1562 @@ func (a *AuthenticationService) Register(args RegisterArgs) (*RegisterResult, er
 
 // @alchemy block {{- end }}
 
-func NewAuthenticationService(
-	// @alchemy replace userDao dao.IUserDao,
-	userDao prisma.IUserDao,
-	jwtService IJwtService,
+// @alchemy block {{- if .Refresh }}
+type RefreshArgs struct {
+	RefreshToken string
+}
+
+type RefreshResult struct {
+	Tokens Tokens `json:"tokens"`
+}
+
+// Refresh exchanges a refresh token for new tokens, the refresh token can't be
+// used again. Using it again revokes every refresh token of its family, as
+// either the user or an attacker has a stolen token.
+func (a *AuthenticationService) Refresh(ctx context.Context, args RefreshArgs) (*RefreshResult, error) {
+	_, err := a.jwtService.ValidateRefreshToken(ctx, args.RefreshToken)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	refreshToken, err := a.refreshTokenDao.GetByTokenHash(ctx, HashToken(args.RefreshToken))
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+
+		return nil, err
+	}
+
+	if refreshToken.Revoked {
+		return nil, a.revokeTokenFamily(ctx, refreshToken.FamilyId)
+	}
+
+	// deleted users can't refresh their tokens
+	_, err = a.userDao.Get(ctx, refreshToken.UserId)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+
+		return nil, err
+	}
+
+	// Revoke fails if a concurrent request rotated the refresh token first
+	err = a.refreshTokenDao.Revoke(ctx, refreshToken.Id)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, a.revokeTokenFamily(ctx, refreshToken.FamilyId)
+		}
+
+		return nil, err
+	}
+
+	tokens, err := a.issueTokens(ctx, refreshToken.UserId, refreshToken.FamilyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshResult{Tokens: *tokens}, nil
+}
+
+// revokeTokenFamily revokes the refresh tokens of a family once one of them
+// is reused, it returns the error of the refresh
+func (a *AuthenticationService) revokeTokenFamily(ctx context.Context, familyId string) error {
+	err := a.refreshTokenDao.RevokeFamily(ctx, familyId)
+	if err != nil {
+		return err
+	}
+
+	return errors.New("invalid refresh token")
+}
+
+// startTokenFamily issues the first tokens of a login, the refresh tokens
+// rotated from them share its family
+func (a *AuthenticationService) startTokenFamily(ctx context.Context, userId string) (*Tokens, error) {
+	familyId, err := GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.issueTokens(ctx, userId, familyId)
+}
+
+// issueTokens generates tokens and stores the hash of the refresh token
+func (a *AuthenticationService) issueTokens(ctx context.Context, userId string, familyId string) (*Tokens, error) {
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := a.jwtService.ValidateRefreshToken(ctx, tokens.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.refreshTokenDao.Create(
+		ctx,
+		// @alchemy replace dao.RefreshTokenCreatePayload{
+		prisma.RefreshTokenCreatePayload{
+			UserId:    userId,
+			FamilyId:  familyId,
+			TokenHash: HashToken(tokens.RefreshToken),
+			ExpiresAt: claims.ExpiresAt.Time,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .Logout }}
+type LogoutArgs struct {
+	AccessToken string
+	// RefreshToken is optional, it's revoked along with the access token
+	RefreshToken string
+}
+
+// Logout revokes the tokens of the current session
+func (a *AuthenticationService) Logout(ctx context.Context, args LogoutArgs) error {
+	claims, err := a.jwtService.ValidateAccessToken(ctx, args.AccessToken)
+	if err != nil {
+		return errors.New("invalid access token")
+	}
+
+	if args.RefreshToken != "" {
+		refreshClaims, err := a.jwtService.ValidateRefreshToken(ctx, args.RefreshToken)
+		if err != nil || refreshClaims.Sub != claims.Sub {
+			return errors.New("invalid refresh token")
+		}
+
+		err = a.jwtService.RevokeToken(ctx, *refreshClaims)
+		if err != nil {
+			return err
+		}
+		// @alchemy block {{- if .Refresh }}
+
+		// the refresh tokens rotated from the same login belong to the session too
+		refreshToken, err := a.refreshTokenDao.GetByTokenHash(ctx, HashToken(args.RefreshToken))
+		if err == nil {
+			err = a.refreshTokenDao.RevokeFamily(ctx, refreshToken.FamilyId)
+		}
+
+		// @alchemy replace if err != nil && !errors.Is(err, dao.ErrNotFound) {
+		if err != nil && !errors.Is(err, shared.ErrNotFound) {
+			return err
+		}
+		// @alchemy block {{- end }}
+	}
+
+	return a.jwtService.RevokeToken(ctx, *claims)
+}
+
+type LogoutEverywhereArgs struct {
+	AccessToken string
+}
+
+// LogoutEverywhere revokes every token of the user issued until now, including
+// the tokens of other sessions
+func (a *AuthenticationService) LogoutEverywhere(ctx context.Context, args LogoutEverywhereArgs) error {
+	claims, err := a.jwtService.ValidateAccessToken(ctx, args.AccessToken)
+	if err != nil {
+		return errors.New("invalid access token")
+	}
+
+	// @alchemy block {{- if .Sessions }}
+
+	err = a.sessionStore.DeleteAllOfUser(ctx, claims.Sub)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy block {{- end }}
+
+	return a.jwtService.RevokeUserTokens(ctx, claims.Sub)
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .PasswordReset }}
+var (
+	PASSWORD_RESET_TOKEN_EXPIRY string = GetEnv("PASSWORD_RESET_TOKEN_EXPIRY", "1h")
+	PASSWORD_RESET_URL          string = GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+)
+
+type RequestPasswordResetArgs struct {
+	Email string
+}
+
+// RequestPasswordReset mails a password reset link to the user. Unknown emails
+// are ignored, so they can't be told apart from the emails of users.
+func (a *AuthenticationService) RequestPasswordReset(ctx context.Context, args RequestPasswordResetArgs) error {
+	user, err := a.userDao.GetByEmail(ctx, args.Email)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	expiry, err := time.ParseDuration(PASSWORD_RESET_TOKEN_EXPIRY)
+	if err != nil {
+		return err
+	}
+
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.passwordResetTokenDao.Create(
+		ctx,
+		// @alchemy replace dao.PasswordResetTokenCreatePayload{
+		prisma.PasswordResetTokenCreatePayload{
+			UserId:    user.Id,
+			TokenHash: HashToken(token),
+			ExpiresAt: time.Now().Add(expiry),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Reset your password within %s by opening the link below.\n\n%s?token=%s\n\nIf you didn't ask to reset your password, you can ignore this email.",
+			expiry,
+			PASSWORD_RESET_URL,
+			token,
+		),
+	})
+}
+
+type ResetPasswordArgs struct {
+	Token    string
+	Password string
+}
+
+// ResetPassword sets a new password with the token of a password reset link.
+// The token can only be used once, and every token of the user issued until
+// now is revoked, so sessions started with the old password end.
+func (a *AuthenticationService) ResetPassword(ctx context.Context, args ResetPasswordArgs) error {
+	passwordResetToken, err := a.passwordResetTokenDao.GetByTokenHash(ctx, HashToken(args.Token))
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return errors.New("invalid password reset token")
+		}
+
+		return err
+	}
+
+	if passwordResetToken.Used || time.Now().After(passwordResetToken.ExpiresAt) {
+		return errors.New("invalid password reset token")
+	}
+
+	hashedPassword, err := a.passwordHasher.Hash(ctx, args.Password)
+	if err != nil {
+		return err
+	}
+
+	// Use fails if a concurrent request used the token first
+	err = a.passwordResetTokenDao.Use(ctx, passwordResetToken.Id)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return errors.New("invalid password reset token")
+		}
+
+		return err
+	}
+
+	// @alchemy replace _, err = a.userDao.Update(ctx, passwordResetToken.UserId, dao.UserUpdatePayload{Password: &hashedPassword})
+	_, err = a.userDao.Update(ctx, passwordResetToken.UserId, prisma.UserUpdatePayload{Password: &hashedPassword})
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return errors.New("invalid password reset token")
+		}
+
+		return err
+	}
+
+	// the other password reset links of the user can't be used anymore
+	err = a.passwordResetTokenDao.UseAllOfUser(ctx, passwordResetToken.UserId)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy block {{- if .Sessions }}
+
+	err = a.sessionStore.DeleteAllOfUser(ctx, passwordResetToken.UserId)
+	if err != nil {
+		return err
+	}
+
+	// @alchemy block {{- end }}
+
+	return a.jwtService.RevokeUserTokens(ctx, passwordResetToken.UserId)
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .EmailVerification }}
+var (
+	EMAIL_VERIFICATION_URL string = GetEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email")
+	// EMAIL_VERIFICATION_REQUIRED blocks the login of users until they verify their email
+	EMAIL_VERIFICATION_REQUIRED string = GetEnv("EMAIL_VERIFICATION_REQUIRED", "false")
+)
+
+var ErrEmailNotVerified = errors.New("email is not verified")
+
+type VerifyEmailArgs struct {
+	Token string
+}
+
+// VerifyEmail verifies the email of a user with the token of a verification
+// link. Verifying an email again does nothing.
+func (a *AuthenticationService) VerifyEmail(ctx context.Context, args VerifyEmailArgs) error {
+	claims, err := a.jwtService.ValidateEmailVerificationToken(ctx, args.Token)
+	if err != nil {
+		return errors.New("invalid email verification token")
+	}
+
+	user, err := a.userDao.Get(ctx, claims.Sub)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return errors.New("invalid email verification token")
+		}
+
+		return err
+	}
+
+	// the token only verifies the email it was sent to, not the one the user changed it to
+	if user.Email != claims.Email {
+		return errors.New("invalid email verification token")
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	// @alchemy replace _, err = a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{EmailVerifiedAt: &now})
+	_, err = a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{EmailVerifiedAt: &now})
+
+	return err
+}
+
+type ResendVerificationArgs struct {
+	Email string
+}
+
+// ResendVerification mails a new verification link to the user. Unknown and
+// verified emails are ignored, so they can't be told apart from the others.
+func (a *AuthenticationService) ResendVerification(ctx context.Context, args ResendVerificationArgs) error {
+	user, err := a.userDao.GetByEmail(ctx, args.Email)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return a.sendVerificationEmail(ctx, *user)
+}
+
+// sendVerificationEmail mails a link verifying the current email of user
+// @alchemy replace func (a *AuthenticationService) sendVerificationEmail(ctx context.Context, user dao.User) error {
+func (a *AuthenticationService) sendVerificationEmail(ctx context.Context, user prisma.User) error {
+	token, err := a.jwtService.GenerateEmailVerificationToken(ctx, Claims{Sub: user.Id, Email: user.Email})
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Verify your email within %s by opening the link below.\n\n%s?token=%s\n\nIf you didn't create an account, you can ignore this email.",
+			JWT_EMAIL_VERIFICATION_TOKEN_EXPIRY,
+			EMAIL_VERIFICATION_URL,
+			*token,
+		),
+	})
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .OAuth }}
+var OAUTH_STATE_EXPIRY string = GetEnv("OAUTH_STATE_EXPIRY", "10m")
+
+type BeginOAuthArgs struct {
+	Provider string
+}
+
+type BeginOAuthResult struct {
+	// Url is the page of the provider the user is redirected to
+	Url string `json:"url"`
+	// State should be kept in a cookie and compared to the state the user returns
+	// with, so nobody else can complete the sign in
+	State string `json:"state"`
+}
+
+// BeginOAuth starts signing a user in with a provider, the state of the sign in
+// is kept until the user returns with a code
+func (a *AuthenticationService) BeginOAuth(ctx context.Context, args BeginOAuthArgs) (*BeginOAuthResult, error) {
+	provider, ok := a.oauthProviders[args.Provider]
+	if !ok {
+		return nil, fmt.Errorf("oauth provider `%s` is not available", args.Provider)
+	}
+
+	expiry, err := time.ParseDuration(OAUTH_STATE_EXPIRY)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	codeVerifier, codeChallenge, err := NewPkce()
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.oauthStateStore.Save(ctx, state, OAuthState{
+		Provider:     args.Provider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(expiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := provider.AuthCodeUrl(ctx, OAuthAuthCodeUrlArgs{State: state, CodeChallenge: codeChallenge, Nonce: nonce})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BeginOAuthResult{Url: url, State: state}, nil
+}
+
+type CompleteOAuthArgs struct {
+	State string
+	Code  string
+}
+
+type CompleteOAuthResult struct {
+	// @alchemy replace User dao.User `json:"user"`
+	User prisma.User `json:"user"`
+	// @alchemy replace Tokens {{ if .MFA }}*Tokens `json:"tokens,omitempty"`{{ else }}Tokens `json:"tokens"`{{ end }}
+	Tokens Tokens `json:"tokens"`
+	// @alchemy block {{- if .MFA }}
+	// MFAToken is returned instead of Tokens when the user enabled MFA, the
+	// provider's sign in doesn't replace the code
+	MFAToken string `json:"mfaToken,omitempty"`
+	// @alchemy block {{- end }}
+}
+
+// CompleteOAuth signs in the user who returned from their provider with a code.
+// Users signing in for the first time are linked to the user with their email,
+// or registered if there is none.
+func (a *AuthenticationService) CompleteOAuth(ctx context.Context, args CompleteOAuthArgs) (*CompleteOAuthResult, error) {
+	oauthState, err := a.oauthStateStore.Take(ctx, args.State)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := a.oauthProviders[oauthState.Provider]
+	if !ok {
+		return nil, fmt.Errorf("oauth provider `%s` is not available", oauthState.Provider)
+	}
+
+	identity, err := provider.Exchange(ctx, OAuthExchangeArgs{
+		Code:         args.Code,
+		CodeVerifier: oauthState.CodeVerifier,
+		Nonce:        oauthState.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.oauthUser(ctx, *identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy block {{- if .MFA }}
+
+	if user.MfaEnabled {
+		mfaToken, err := a.jwtService.GenerateMFAToken(ctx, Claims{Sub: user.Id})
+		if err != nil {
+			return nil, err
+		}
+
+		return &CompleteOAuthResult{User: *user, MFAToken: *mfaToken}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy replace tokens, err := a.{{ if .Refresh }}startTokenFamily(ctx, user.Id){{ else }}jwtService.GenerateTokens(ctx, Claims{Sub: user.Id}){{ end }}
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: user.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy replace return &CompleteOAuthResult{User: *user, Tokens: {{ if not .MFA }}*{{ end }}tokens}, nil
+	return &CompleteOAuthResult{User: *user, Tokens: *tokens}, nil
+}
+
+// oauthUser returns the user an identity is linked to, or links it first
+// @alchemy replace func (a *AuthenticationService) oauthUser(ctx context.Context, identity OAuthIdentity) (*dao.User, error) {
+func (a *AuthenticationService) oauthUser(ctx context.Context, identity OAuthIdentity) (*prisma.User, error) {
+	linkedAccount, err := a.linkedAccountDao.GetByProviderUserId(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return a.userDao.Get(ctx, linkedAccount.UserId)
+	}
+
+	// @alchemy replace if !errors.Is(err, dao.ErrNotFound) {
+	if !errors.Is(err, shared.ErrNotFound) {
+		return nil, err
+	}
+
+	// anyone can claim an unverified email at some providers, linking it would
+	// hand them the user with that email
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("oauth provider didn't verify the email")
+	}
+
+	user, err := a.userDao.GetByEmail(ctx, identity.Email)
+	// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+	if errors.Is(err, shared.ErrNotFound) {
+		user, err = a.registerWithoutPassword(ctx, identity.Email, identity.FirstName, identity.LastName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.linkedAccountDao.Create(
+		ctx,
+		// @alchemy replace dao.LinkedAccountCreatePayload{
+		prisma.LinkedAccountCreatePayload{
+			UserId:         user.Id,
+			Provider:       identity.Provider,
+			ProviderUserId: identity.Subject,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy block {{- if .EmailVerification }}
+
+	// the provider verified the email already
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		// @alchemy replace return a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{EmailVerifiedAt: &now})
+		return a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{EmailVerifiedAt: &now})
+	}
+
+	// @alchemy block {{- end }}
+
+	return user, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if or .OAuth .MagicLink }}
+
+// registerWithoutPassword creates a user who signed in without a password,
+// with a random one they can replace by resetting it
+// @alchemy replace func (a *AuthenticationService) registerWithoutPassword(ctx context.Context, email string, firstName *string, lastName *string) (*dao.User, error) {
+func (a *AuthenticationService) registerWithoutPassword(ctx context.Context, email string, firstName *string, lastName *string) (*prisma.User, error) {
+	password, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := a.passwordHasher.Hash(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userDao.Create(
+		ctx,
+		// @alchemy replace dao.UserCreatePayload{
+		prisma.UserCreatePayload{
+			FirstName: GetIfPresent(firstName),
+			LastName:  GetIfPresent(lastName),
+			Email:     email,
+			Password:  hashedPassword,
+		},
+	)
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .MFA }}
+var (
+	// MFA_ISSUER is the name authenticator apps show the codes under
+	MFA_ISSUER string = GetEnv("MFA_ISSUER", "Alchemy")
+)
+
+// recoveryCodesCount is the number of recovery codes a user gets, each can be
+// used once instead of a code of their authenticator app
+const recoveryCodesCount = 10
+
+var ErrInvalidMFACode = errors.New("invalid mfa code")
+
+type EnrollMFAArgs struct {
+	AccessToken string
+}
+
+type EnrollMFAResult struct {
+	// Secret is typed into authenticator apps which can't scan Uri
+	Secret string `json:"secret"`
+	// Uri is shown as a QR code, authenticator apps add the secret by scanning it
+	Uri string `json:"uri"`
+}
+
+// EnrollMFA gives the user a new secret for their authenticator app. MFA is
+// only enabled once the user confirms they added it with a code.
+func (a *AuthenticationService) EnrollMFA(ctx context.Context, args EnrollMFAArgs) (*EnrollMFAResult, error) {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MfaEnabled {
+		return nil, errors.New("mfa is already enabled")
+	}
+
+	secret, err := GenerateTotpSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy replace _, err = a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{MfaSecret: &secret})
+	_, err = a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{MfaSecret: &secret})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnrollMFAResult{Secret: secret, Uri: TotpUri(MFA_ISSUER, user.Email, secret)}, nil
+}
+
+type ConfirmMFAArgs struct {
+	AccessToken string
+	Code        string
+}
+
+type RecoveryCodesResult struct {
+	// RecoveryCodes are only shown once, the user should write them down
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// ConfirmMFA enables MFA with a code of the authenticator app the user added
+// the secret to, and returns their recovery codes
+func (a *AuthenticationService) ConfirmMFA(ctx context.Context, args ConfirmMFAArgs) (*RecoveryCodesResult, error) {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MfaEnabled {
+		return nil, errors.New("mfa is already enabled")
+	}
+
+	if user.MfaSecret == nil || *user.MfaSecret == "" {
+		return nil, errors.New("mfa is not enrolled")
+	}
+
+	err = a.allowMFAAttempt(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	codeIsValid, err := a.useTotpCode(ctx, *user, args.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !codeIsValid {
+		return nil, ErrInvalidMFACode
+	}
+
+	mfaEnabled := true
+	// @alchemy replace _, err = a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{MfaEnabled: &mfaEnabled})
+	_, err = a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{MfaEnabled: &mfaEnabled})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.replaceRecoveryCodes(ctx, user.Id)
+}
+
+type VerifyMFAArgs struct {
+	MFAToken string
+	// Code is a code of the user's authenticator app, or one of their recovery codes
+	Code string
+	// @alchemy block {{- if .Sessions }}
+	// Session and SessionToken are the ones of the login, see LoginArgs
+	Session      bool
+	SessionToken string
+	// @alchemy block {{- end }}
+}
+
+// VerifyMFA completes a login which returned an MFA token
+func (a *AuthenticationService) VerifyMFA(ctx context.Context, args VerifyMFAArgs) (*LoginResult, error) {
+	claims, err := a.jwtService.ValidateMFAToken(ctx, args.MFAToken)
+	if err != nil {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	user, err := a.userDao.Get(ctx, claims.Sub)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid mfa token")
+		}
+
+		return nil, err
+	}
+
+	if !user.MfaEnabled {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	err = a.verifyMFACode(ctx, *user, args.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token is only used once, so a login can't be completed again with
+	// another code
+	err = a.jwtService.RevokeToken(ctx, *claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy block {{- if .Sessions }}
+
+	if args.Session {
+		session, err := a.startSession(ctx, user.Id, args.SessionToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{User: *user, Session: session}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy replace tokens, err := a.{{ if .Refresh }}startTokenFamily(ctx, user.Id){{ else }}jwtService.GenerateTokens(ctx, Claims{Sub: user.Id}){{ end }}
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: user.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy replace return &LoginResult{User: *user, Tokens: tokens}, nil
+	return &LoginResult{User: *user, Tokens: *tokens}, nil
+}
+
+type RegenerateRecoveryCodesArgs struct {
+	AccessToken string
+	Code        string
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, the old
+// ones can't be used anymore
+func (a *AuthenticationService) RegenerateRecoveryCodes(ctx context.Context, args RegenerateRecoveryCodesArgs) (*RecoveryCodesResult, error) {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MfaEnabled {
+		return nil, errors.New("mfa is not enabled")
+	}
+
+	err = a.verifyMFACode(ctx, *user, args.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.replaceRecoveryCodes(ctx, user.Id)
+}
+
+type DisableMFAArgs struct {
+	AccessToken string
+	Code        string
+}
+
+// DisableMFA turns MFA off with a code, so a stolen access token alone can't
+// turn it off
+func (a *AuthenticationService) DisableMFA(ctx context.Context, args DisableMFAArgs) error {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if !user.MfaEnabled {
+		return errors.New("mfa is not enabled")
+	}
+
+	err = a.verifyMFACode(ctx, *user, args.Code)
+	if err != nil {
+		return err
+	}
+
+	mfaSecret := ""
+	mfaEnabled := false
+	// @alchemy replace _, err = a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{MfaSecret: &mfaSecret, MfaEnabled: &mfaEnabled})
+	_, err = a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{MfaSecret: &mfaSecret, MfaEnabled: &mfaEnabled})
+	if err != nil {
+		return err
+	}
+
+	return a.recoveryCodeDao.DeleteAllOfUser(ctx, user.Id)
+}
+
+// allowMFAAttempt limits the codes tried per user, a code is guessed a lot
+// easier than a password
+func (a *AuthenticationService) allowMFAAttempt(ctx context.Context, userId string) error {
+	allowed, err := a.mfaRateLimiter.Allow(ctx, "mfa:"+userId)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return ErrTooManyAttempts
+	}
+
+	return nil
+}
+
+// verifyMFACode checks a code of the user's authenticator app, or else uses up
+// the recovery code it matches
+// @alchemy replace func (a *AuthenticationService) verifyMFACode(ctx context.Context, user dao.User, code string) error {
+func (a *AuthenticationService) verifyMFACode(ctx context.Context, user prisma.User, code string) error {
+	err := a.allowMFAAttempt(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	if user.MfaSecret != nil && *user.MfaSecret != "" {
+		codeIsValid, err := a.useTotpCode(ctx, user, code)
+		if err != nil || codeIsValid {
+			return err
+		}
+	}
+
+	err = a.recoveryCodeDao.Use(ctx, user.Id, HashRecoveryCode(code))
+	// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+	if errors.Is(err, shared.ErrNotFound) {
+		return ErrInvalidMFACode
+	}
+
+	return err
+}
+
+// useTotpCode checks a code of the user's authenticator app and records its
+// time step, so the code can't be replayed
+// @alchemy replace func (a *AuthenticationService) useTotpCode(ctx context.Context, user dao.User, code string) (bool, error) {
+func (a *AuthenticationService) useTotpCode(ctx context.Context, user prisma.User, code string) (bool, error) {
+	step, codeIsValid, err := ValidateTotpCode(*user.MfaSecret, code, time.Now(), user.MfaTotpStep)
+	if err != nil || !codeIsValid {
+		return false, err
+	}
+
+	// the step is only recorded if it's after the last one, so of two
+	// requests racing with the same code only one is accepted
+	err = a.userDao.UseMfaTotpStep(ctx, user.Id, step)
+	// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+	if errors.Is(err, shared.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a *AuthenticationService) replaceRecoveryCodes(ctx context.Context, userId string) (*RecoveryCodesResult, error) {
+	err := a.recoveryCodeDao.DeleteAllOfUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := []string{}
+	for i := 0; i < recoveryCodesCount; i++ {
+		recoveryCode, err := GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = a.recoveryCodeDao.Create(
+			ctx,
+			// @alchemy replace dao.RecoveryCodeCreatePayload{
+			prisma.RecoveryCodeCreatePayload{
+				UserId:   userId,
+				CodeHash: HashRecoveryCode(recoveryCode),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+	}
+
+	return &RecoveryCodesResult{RecoveryCodes: recoveryCodes}, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .MagicLink }}
+var (
+	MAGIC_LINK_TOKEN_EXPIRY string = GetEnv("MAGIC_LINK_TOKEN_EXPIRY", "15m")
+	MAGIC_LINK_URL          string = GetEnv("MAGIC_LINK_URL", "http://localhost:3000/magic-link")
+	// MAGIC_LINK_AUTO_REGISTER registers the unknown emails magic links are redeemed with
+	MAGIC_LINK_AUTO_REGISTER string = GetEnv("MAGIC_LINK_AUTO_REGISTER", "false")
+)
+
+type RequestMagicLinkArgs struct {
+	Email string
+}
+
+// RequestMagicLink mails a link which logs the user in. Unknown emails are
+// ignored unless MAGIC_LINK_AUTO_REGISTER is set, so they can't be told apart
+// from the emails of users. The links requested per email are limited, as each
+// one sends a mail.
+func (a *AuthenticationService) RequestMagicLink(ctx context.Context, args RequestMagicLinkArgs) error {
+	allowed, err := a.magicLinkRateLimiter.Allow(ctx, "magic-link:"+strings.ToLower(args.Email))
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return ErrTooManyAttempts
+	}
+
+	_, err = a.userDao.GetByEmail(ctx, args.Email)
+	if err != nil {
+		// @alchemy replace if !errors.Is(err, dao.ErrNotFound) {
+		if !errors.Is(err, shared.ErrNotFound) {
+			return err
+		}
+
+		if MAGIC_LINK_AUTO_REGISTER != "true" {
+			return nil
+		}
+	}
+
+	expiry, err := time.ParseDuration(MAGIC_LINK_TOKEN_EXPIRY)
+	if err != nil {
+		return err
+	}
+
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.magicLinkTokenDao.Create(
+		ctx,
+		// @alchemy replace dao.MagicLinkTokenCreatePayload{
+		prisma.MagicLinkTokenCreatePayload{
+			Email:     args.Email,
+			TokenHash: HashToken(token),
+			ExpiresAt: time.Now().Add(expiry),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, Mail{
+		To:      args.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Log in within %s by opening the link below.\n\n%s?token=%s\n\nIf you didn't ask to log in, you can ignore this email.",
+			expiry,
+			MAGIC_LINK_URL,
+			token,
+		),
+	})
+}
+
+type RedeemMagicLinkArgs struct {
+	Token string
+}
+
+type RedeemMagicLinkResult struct {
+	// @alchemy replace User dao.User `json:"user"`
+	User prisma.User `json:"user"`
+	// @alchemy replace Tokens {{ if .MFA }}*Tokens `json:"tokens,omitempty"`{{ else }}Tokens `json:"tokens"`{{ end }}
+	Tokens Tokens `json:"tokens"`
+	// @alchemy block {{- if .MFA }}
+	// MFAToken is returned instead of Tokens when the user enabled MFA, the
+	// link doesn't replace the code
+	MFAToken string `json:"mfaToken,omitempty"`
+	// @alchemy block {{- end }}
+}
+
+// RedeemMagicLink logs the user in with the token of a magic link. The token
+// can only be used once, and the other links of the email can't be used
+// anymore.
+func (a *AuthenticationService) RedeemMagicLink(ctx context.Context, args RedeemMagicLinkArgs) (*RedeemMagicLinkResult, error) {
+	magicLinkToken, err := a.magicLinkTokenDao.GetByTokenHash(ctx, HashToken(args.Token))
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid magic link token")
+		}
+
+		return nil, err
+	}
+
+	if magicLinkToken.Used || time.Now().After(magicLinkToken.ExpiresAt) {
+		return nil, errors.New("invalid magic link token")
+	}
+
+	// Use fails if a concurrent request used the token first
+	err = a.magicLinkTokenDao.Use(ctx, magicLinkToken.Id)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid magic link token")
+		}
+
+		return nil, err
+	}
+
+	err = a.magicLinkTokenDao.UseAllOfEmail(ctx, magicLinkToken.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userDao.GetByEmail(ctx, magicLinkToken.Email)
+	// @alchemy replace if errors.Is(err, dao.ErrNotFound) && MAGIC_LINK_AUTO_REGISTER == "true" {
+	if errors.Is(err, shared.ErrNotFound) && MAGIC_LINK_AUTO_REGISTER == "true" {
+		user, err = a.registerWithoutPassword(ctx, magicLinkToken.Email, nil, nil)
+	}
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid magic link token")
+		}
+
+		return nil, err
+	}
+
+	// @alchemy block {{- if .EmailVerification }}
+
+	// opening the link verified the email
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		// @alchemy replace user, err = a.userDao.Update(ctx, user.Id, dao.UserUpdatePayload{EmailVerifiedAt: &now})
+		user, err = a.userDao.Update(ctx, user.Id, prisma.UserUpdatePayload{EmailVerifiedAt: &now})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+
+	if user.MfaEnabled {
+		mfaToken, err := a.jwtService.GenerateMFAToken(ctx, Claims{Sub: user.Id})
+		if err != nil {
+			return nil, err
+		}
+
+		return &RedeemMagicLinkResult{User: *user, MFAToken: *mfaToken}, nil
+	}
+
+	// @alchemy block {{- end }}
+
+	// @alchemy replace tokens, err := a.{{ if .Refresh }}startTokenFamily(ctx, user.Id){{ else }}jwtService.GenerateTokens(ctx, Claims{Sub: user.Id}){{ end }}
+	tokens, err := a.jwtService.GenerateTokens(ctx, Claims{Sub: user.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	// @alchemy replace return &RedeemMagicLinkResult{User: *user, Tokens: {{ if not .MFA }}*{{ end }}tokens}, nil
+	return &RedeemMagicLinkResult{User: *user, Tokens: *tokens}, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if or .MFA .APIKeys }}
+
+// accessTokenUser returns the user of an access token, for the methods a
+// logged in user calls
+// @alchemy replace func (a *AuthenticationService) accessTokenUser(ctx context.Context, accessToken string) (*dao.User, error) {
+func (a *AuthenticationService) accessTokenUser(ctx context.Context, accessToken string) (*prisma.User, error) {
+	claims, err := a.jwtService.ValidateAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, errors.New("invalid access token")
+	}
+
+	user, err := a.userDao.Get(ctx, claims.Sub)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, errors.New("invalid access token")
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .APIKeys }}
+// API_KEY_PREFIX starts every api key, so leaked keys are easy to recognize
+var API_KEY_PREFIX string = GetEnv("API_KEY_PREFIX", "ak")
+
+// apiKeyLastUsedInterval is how often the last use of an api key is written, so
+// validating a key doesn't write on every request
+const apiKeyLastUsedInterval = time.Minute
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+type CreateAPIKeyArgs struct {
+	AccessToken string
+	Name        string
+	Scopes      []string
+	// ExpiresAt is optional, the api key doesn't expire without it
+	ExpiresAt *time.Time
+}
+
+type CreateAPIKeyResult struct {
+	// @alchemy replace ApiKey dao.ApiKey `json:"apiKey"`
+	ApiKey prisma.ApiKey `json:"apiKey"`
+	// Key is only known when the api key is created, it is stored hashed
+	Key string `json:"key"`
+}
+
+func (a *AuthenticationService) CreateAPIKey(ctx context.Context, args CreateAPIKeyArgs) (*CreateAPIKeyResult, error) {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(args.Name) == "" {
+		return nil, errors.New("api key name is required")
+	}
+
+	for _, scope := range args.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			return nil, fmt.Errorf("invalid api key scope %q", scope)
+		}
+	}
+
+	if args.ExpiresAt != nil && !args.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("api key expiry must be in the future")
+	}
+
+	return a.createAPIKey(ctx, user.Id, args.Name, strings.Join(args.Scopes, " "), args.ExpiresAt)
+}
+
+type ListAPIKeysArgs struct {
+	AccessToken string
+}
+
+type ListAPIKeysResult struct {
+	// @alchemy replace ApiKeys []dao.ApiKey `json:"apiKeys"`
+	ApiKeys []prisma.ApiKey `json:"apiKeys"`
+}
+
+func (a *AuthenticationService) ListAPIKeys(ctx context.Context, args ListAPIKeysArgs) (*ListAPIKeysResult, error) {
+	user, err := a.accessTokenUser(ctx, args.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := a.apiKeyDao.ListByUser(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListAPIKeysResult{ApiKeys: apiKeys}, nil
+}
+
+type RotateAPIKeyArgs struct {
+	AccessToken string
+	Id          string
+}
+
+// RotateAPIKey revokes an api key and creates a new one with its name, scopes
+// and expiry
+func (a *AuthenticationService) RotateAPIKey(ctx context.Context, args RotateAPIKeyArgs) (*CreateAPIKeyResult, error) {
+	apiKey, err := a.userAPIKey(ctx, args.AccessToken, args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoke fails if a concurrent request rotated the api key first
+	err = a.apiKeyDao.Revoke(ctx, apiKey.Id)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, err
+	}
+
+	return a.createAPIKey(ctx, apiKey.UserId, apiKey.Name, apiKey.Scope, apiKey.ExpiresAt)
+}
+
+type RevokeAPIKeyArgs struct {
+	AccessToken string
+	Id          string
+}
+
+func (a *AuthenticationService) RevokeAPIKey(ctx context.Context, args RevokeAPIKeyArgs) error {
+	apiKey, err := a.userAPIKey(ctx, args.AccessToken, args.Id)
+	if err != nil {
+		return err
+	}
+
+	err = a.apiKeyDao.Revoke(ctx, apiKey.Id)
+	// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+	if errors.Is(err, shared.ErrNotFound) {
+		return ErrInvalidAPIKey
+	}
+
+	return err
+}
+
+// ValidateAPIKey checks an api key of the form <prefix>_<secret>. The claims'
+// subject is the user of the key, its id is the id of the key, and Scope holds
+// the scopes of the key.
+func (a *AuthenticationService) ValidateAPIKey(ctx context.Context, key string) (*Claims, error) {
+	separator := strings.LastIndex(key, "_")
+	if separator == -1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := a.apiKeyDao.GetByPrefix(ctx, key[:separator])
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, err
+	}
+
+	secretHash := HashToken(key[separator+1:])
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(apiKey.SecretHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.Revoked || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyLastUsedInterval {
+		err = a.apiKeyDao.SetLastUsedAt(ctx, apiKey.Id, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	claims := Claims{
+		Sub:              apiKey.UserId,
+		Scope:            apiKey.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{ID: apiKey.Id},
+	}
+	if apiKey.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*apiKey.ExpiresAt)
+	}
+
+	return &claims, nil
+}
+
+func (a *AuthenticationService) createAPIKey(ctx context.Context, userId string, name string, scope string, expiresAt *time.Time) (*CreateAPIKeyResult, error) {
+	id, err := GenerateRandomToken(6)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := API_KEY_PREFIX + "_" + id
+	apiKey, err := a.apiKeyDao.Create(
+		ctx,
+		// @alchemy replace dao.ApiKeyCreatePayload{
+		prisma.ApiKeyCreatePayload{
+			UserId:     userId,
+			Name:       name,
+			Prefix:     prefix,
+			SecretHash: HashToken(secret),
+			Scope:      scope,
+			ExpiresAt:  expiresAt,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateAPIKeyResult{ApiKey: *apiKey, Key: prefix + "_" + secret}, nil
+}
+
+// userAPIKey returns an api key of the user of an access token, the keys of
+// other users aren't found
+// @alchemy replace func (a *AuthenticationService) userAPIKey(ctx context.Context, accessToken string, id string) (*dao.ApiKey, error) {
+func (a *AuthenticationService) userAPIKey(ctx context.Context, accessToken string, id string) (*prisma.ApiKey, error) {
+	user, err := a.accessTokenUser(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := a.apiKeyDao.Get(ctx, id)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) || errors.Is(err, dao.ErrInvalid) {
+		if errors.Is(err, shared.ErrNotFound) || errors.Is(err, shared.ErrInvalid) {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, err
+	}
+
+	if apiKey.UserId != user.Id {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .Sessions }}
+// sessionTouchInterval limits how often the last use of a session is recorded,
+// the idle timeout doesn't need to be more precise
+const sessionTouchInterval = time.Minute
+
+// startSession starts a session of the user, ending the session of
+// previousToken so a session token set before a login can't be used after it
+func (a *AuthenticationService) startSession(ctx context.Context, userId string, previousToken string) (*SessionResult, error) {
+	if previousToken != "" {
+		err := a.endSession(ctx, previousToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	absoluteTimeout, err := time.ParseDuration(SESSION_ABSOLUTE_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session, err := a.sessionStore.Create(ctx, Session{
+		UserId:     userId,
+		TokenHash:  HashToken(token),
+		ExpiresAt:  now.Add(absoluteTimeout),
+		LastSeenAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionResult{Token: token, CsrfToken: CsrfToken(token), ExpiresAt: session.ExpiresAt}, nil
+}
+
+// ValidateSession returns ErrInvalidSession for unknown sessions and the ones
+// past their idle or absolute timeout, the latter are ended
+func (a *AuthenticationService) ValidateSession(ctx context.Context, token string) (*Session, error) {
+	idleTimeout, err := time.ParseDuration(SESSION_IDLE_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	session, err := a.sessionStore.Get(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.After(session.ExpiresAt) || now.Sub(session.LastSeenAt) > idleTimeout {
+		err = a.sessionStore.Delete(ctx, *session)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidSession
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		err = a.sessionStore.Touch(ctx, *session, now)
+		if err != nil {
+			return nil, err
+		}
+
+		session.LastSeenAt = now
+	}
+
+	return session, nil
+}
+
+// endSession ends the session of token, unknown sessions already ended
+func (a *AuthenticationService) endSession(ctx context.Context, token string) error {
+	session, err := a.sessionStore.Get(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrInvalidSession) {
+			return nil
+		}
+
+		return err
+	}
+
+	return a.sessionStore.Delete(ctx, *session)
+}
+
+type EndSessionArgs struct {
+	Token string
+}
+
+// EndSession logs out of the session of a session token, ending a session
+// again does nothing. The session cookie is deleted with ClearSessionCookie.
+func (a *AuthenticationService) EndSession(ctx context.Context, args EndSessionArgs) error {
+	return a.endSession(ctx, args.Token)
+}
+
+type EndAllSessionsArgs struct {
+	Token string
+}
+
+// EndAllSessions ends every session of the user of a session token, including
+// the sessions on other devices
+func (a *AuthenticationService) EndAllSessions(ctx context.Context, args EndAllSessionsArgs) error {
+	session, err := a.ValidateSession(ctx, args.Token)
+	if err != nil {
+		return err
+	}
+
+	return a.sessionStore.DeleteAllOfUser(ctx, session.UserId)
+}
+
+// @alchemy block {{- end }}
+
+func NewAuthenticationService(
+	// @alchemy replace userDao dao.IUserDao,
+	userDao prisma.IUserDao,
+	jwtService IJwtService,
+	passwordHasher IPasswordHasher,
+	// @alchemy block {{- if .Refresh }}
+	// @alchemy replace refreshTokenDao dao.IRefreshTokenDao,
+	refreshTokenDao prisma.IRefreshTokenDao,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .PasswordReset }}
+	// @alchemy replace passwordResetTokenDao dao.IPasswordResetTokenDao,
+	passwordResetTokenDao prisma.IPasswordResetTokenDao,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if or .PasswordReset .EmailVerification .MagicLink }}
+	mailer IMailer,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .OAuth }}
+	// @alchemy replace linkedAccountDao dao.ILinkedAccountDao,
+	linkedAccountDao prisma.ILinkedAccountDao,
+	oauthStateStore IOAuthStateStore,
+	oauthProviders []IOAuthProvider,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	// @alchemy replace recoveryCodeDao dao.IRecoveryCodeDao,
+	recoveryCodeDao prisma.IRecoveryCodeDao,
+	mfaRateLimiter IRateLimiter,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MagicLink }}
+	// @alchemy replace magicLinkTokenDao dao.IMagicLinkTokenDao,
+	magicLinkTokenDao prisma.IMagicLinkTokenDao,
+	magicLinkRateLimiter IRateLimiter,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .APIKeys }}
+	// @alchemy replace apiKeyDao dao.IApiKeyDao,
+	apiKeyDao prisma.IApiKeyDao,
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Sessions }}
+	sessionStore ISessionStore,
+	// @alchemy block {{- end }}
 ) IAuthenticationService {
 	return &AuthenticationService{
-		userDao:    userDao,
-		jwtService: jwtService,
+		userDao:        userDao,
+		jwtService:     jwtService,
+		passwordHasher: passwordHasher,
+		// @alchemy block {{- if .Refresh }}
+		refreshTokenDao: refreshTokenDao,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .PasswordReset }}
+		passwordResetTokenDao: passwordResetTokenDao,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if or .PasswordReset .EmailVerification .MagicLink }}
+		mailer: mailer,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .OAuth }}
+		linkedAccountDao: linkedAccountDao,
+		oauthStateStore:  oauthStateStore,
+		oauthProviders:   lo.KeyBy(oauthProviders, func(p IOAuthProvider) string { return p.Name() }),
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MFA }}
+		recoveryCodeDao: recoveryCodeDao,
+		mfaRateLimiter:  mfaRateLimiter,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .MagicLink }}
+		magicLinkTokenDao:    magicLinkTokenDao,
+		magicLinkRateLimiter: magicLinkRateLimiter,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .APIKeys }}
+		apiKeyDao: apiKeyDao,
+		// @alchemy block {{- end }}
+		// @alchemy block {{- if .Sessions }}
+		sessionStore: sessionStore,
+		// @alchemy block {{- end }}
 	}
 }
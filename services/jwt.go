@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -9,14 +10,34 @@ import (
 )
 
 type IJwtService interface {
-	GenerateAccessToken(Claims) (*string, error)
-	GenerateRefreshToken(Claims) (*string, error)
-	GenerateTokens(Claims) (*Tokens, error)
-	ValidateAccessToken(string) (*Claims, error)
-	ValidateRefreshToken(string) (*Claims, error)
+	GenerateAccessToken(context.Context, Claims) (*string, error)
+	GenerateRefreshToken(context.Context, Claims) (*string, error)
+	GenerateTokens(context.Context, Claims) (*Tokens, error)
+	ValidateAccessToken(context.Context, string) (*Claims, error)
+	ValidateRefreshToken(context.Context, string) (*Claims, error)
+	// @alchemy block {{- if .EmailVerification }}
+	GenerateEmailVerificationToken(context.Context, Claims) (*string, error)
+	ValidateEmailVerificationToken(context.Context, string) (*Claims, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .MFA }}
+	// GenerateMFAToken issues the token of a login waiting for the code of the
+	// user's authenticator app
+	GenerateMFAToken(context.Context, Claims) (*string, error)
+	ValidateMFAToken(context.Context, string) (*Claims, error)
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .Logout }}
+	// RevokeToken revokes the token of claims until it expires
+	RevokeToken(context.Context, Claims) error
+	// RevokeUserTokens revokes every token of a user issued until now
+	RevokeUserTokens(context.Context, string) error
+	// @alchemy block {{- end }}
 }
 
-type JwtService struct{}
+type JwtService struct {
+	// @alchemy block {{- if .Logout }}
+	revocationStore ITokenRevocationStore
+	// @alchemy block {{- end }}
+}
 
 type Tokens struct {
 	AccessToken  string `json:"accessToken"`
@@ -27,20 +48,65 @@ type Claims struct {
 	Sub                  string `json:"sub"`
 	Role                 string `json:"role"`
 	jwt.RegisteredClaims        // Use this for standard fields like exp, iss, etc.
+	// @alchemy block {{- if .EmailVerification }}
+	// Email is the address an email verification token verifies
+	Email string `json:"email,omitempty"`
+	// @alchemy block {{- end }}
+	// @alchemy block {{- if .APIKeys }}
+	// Scope is the space separated scopes of an api key, it's empty for access
+	// tokens which aren't scoped
+	Scope string `json:"scope,omitempty"`
+	// @alchemy block {{- end }}
 }
 
 var (
 	JWT_ACCESS_TOKEN_SECRET  string = GetEnv("JWT_ACCESS_TOKEN_SECRET", "access-token")
 	JWT_ACCESS_TOKEN_EXPIRY  string = GetEnv("JWT_ACCESS_TOKEN_EXPIRY", "10m")
-	JWT_REFRESH_TOKEN_SECRET string = GetEnv("JWT_ACCESS_TOKEN_SECRET", "refresh-secret")
-	JWT_REFRESH_TOKEN_EXPIRY string = GetEnv("JWT_ACCESS_TOKEN_EXPIRY", "168h") // 7d
+	JWT_REFRESH_TOKEN_SECRET string = GetEnv("JWT_REFRESH_TOKEN_SECRET", "refresh-secret")
+	JWT_REFRESH_TOKEN_EXPIRY string = GetEnv("JWT_REFRESH_TOKEN_EXPIRY", "168h") // 7d
 )
 
+// @alchemy block {{- if .EmailVerification }}
+
+var (
+	JWT_EMAIL_VERIFICATION_TOKEN_SECRET string = GetEnv("JWT_EMAIL_VERIFICATION_TOKEN_SECRET", "email-verification-secret")
+	JWT_EMAIL_VERIFICATION_TOKEN_EXPIRY string = GetEnv("JWT_EMAIL_VERIFICATION_TOKEN_EXPIRY", "24h")
+)
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .MFA }}
+
+var (
+	JWT_MFA_TOKEN_SECRET string = GetEnv("JWT_MFA_TOKEN_SECRET", "mfa-secret")
+	JWT_MFA_TOKEN_EXPIRY string = GetEnv("JWT_MFA_TOKEN_EXPIRY", "5m")
+)
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .Logout }}
+
+func init() {
+	// issued at times are compared with the times tokens of a user are revoked
+	// at, so tokens issued right after a revocation aren't revoked with it
+	jwt.TimePrecision = time.Millisecond
+}
+
+// @alchemy block {{- end }}
+
 func (j *JwtService) generateToken(claims Claims, secret string, expiry string) (*string, error) {
 	parsedExpiry, err := time.ParseDuration(expiry)
 	if err != nil {
 		return nil, err
 	}
+
+	// the jti makes every token unique, even within the same second, so a
+	// single token can be revoked
+	claims.ID, err = GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
 	claims.IssuedAt = jwt.NewNumericDate(time.Now())
 	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(parsedExpiry))
 
@@ -50,7 +116,7 @@ func (j *JwtService) generateToken(claims Claims, secret string, expiry string)
 	return &signedToken, nil
 }
 
-func (j *JwtService) validateToken(token string, secret string) (*Claims, error) {
+func (j *JwtService) validateToken(ctx context.Context, token string, secret string) (*Claims, error) {
 	validatedToken, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
 		// Don't forget to validate the alg is what you expect:
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -68,24 +134,35 @@ func (j *JwtService) validateToken(token string, secret string) (*Claims, error)
 		return nil, errors.New("invalid token")
 	}
 
+	// @alchemy block {{- if .Logout }}
+	revoked, err := j.revocationStore.IsRevoked(ctx, *claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+
+	// @alchemy block {{- end }}
 	return claims, nil
 }
 
-func (j *JwtService) GenerateAccessToken(claims Claims) (*string, error) {
+func (j *JwtService) GenerateAccessToken(ctx context.Context, claims Claims) (*string, error) {
 	return j.generateToken(claims, JWT_ACCESS_TOKEN_SECRET, JWT_ACCESS_TOKEN_EXPIRY)
 }
 
-func (j *JwtService) GenerateRefreshToken(claims Claims) (*string, error) {
+func (j *JwtService) GenerateRefreshToken(ctx context.Context, claims Claims) (*string, error) {
 	return j.generateToken(claims, JWT_REFRESH_TOKEN_SECRET, JWT_REFRESH_TOKEN_EXPIRY)
 }
 
-func (j *JwtService) GenerateTokens(claims Claims) (*Tokens, error) {
-	accessToken, err := j.GenerateAccessToken(claims)
+func (j *JwtService) GenerateTokens(ctx context.Context, claims Claims) (*Tokens, error) {
+	accessToken, err := j.GenerateAccessToken(ctx, claims)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := j.GenerateRefreshToken(claims)
+	refreshToken, err := j.GenerateRefreshToken(ctx, claims)
 	if err != nil {
 		return nil, err
 	}
@@ -93,14 +170,73 @@ func (j *JwtService) GenerateTokens(claims Claims) (*Tokens, error) {
 	return &Tokens{AccessToken: *accessToken, RefreshToken: *refreshToken}, nil
 }
 
-func (j *JwtService) ValidateAccessToken(token string) (*Claims, error) {
-	return j.validateToken(token, JWT_ACCESS_TOKEN_SECRET)
+func (j *JwtService) ValidateAccessToken(ctx context.Context, token string) (*Claims, error) {
+	return j.validateToken(ctx, token, JWT_ACCESS_TOKEN_SECRET)
 }
 
-func (j *JwtService) ValidateRefreshToken(token string) (*Claims, error) {
-	return j.validateToken(token, JWT_REFRESH_TOKEN_SECRET)
+func (j *JwtService) ValidateRefreshToken(ctx context.Context, token string) (*Claims, error) {
+	return j.validateToken(ctx, token, JWT_REFRESH_TOKEN_SECRET)
 }
 
-func NewJwtService() IJwtService {
-	return &JwtService{}
+// @alchemy block {{- if .EmailVerification }}
+
+func (j *JwtService) GenerateEmailVerificationToken(ctx context.Context, claims Claims) (*string, error) {
+	return j.generateToken(claims, JWT_EMAIL_VERIFICATION_TOKEN_SECRET, JWT_EMAIL_VERIFICATION_TOKEN_EXPIRY)
+}
+
+func (j *JwtService) ValidateEmailVerificationToken(ctx context.Context, token string) (*Claims, error) {
+	return j.validateToken(ctx, token, JWT_EMAIL_VERIFICATION_TOKEN_SECRET)
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .MFA }}
+
+func (j *JwtService) GenerateMFAToken(ctx context.Context, claims Claims) (*string, error) {
+	return j.generateToken(claims, JWT_MFA_TOKEN_SECRET, JWT_MFA_TOKEN_EXPIRY)
+}
+
+func (j *JwtService) ValidateMFAToken(ctx context.Context, token string) (*Claims, error) {
+	return j.validateToken(ctx, token, JWT_MFA_TOKEN_SECRET)
+}
+
+// @alchemy block {{- end }}
+
+// @alchemy block {{- if .Logout }}
+
+func (j *JwtService) RevokeToken(ctx context.Context, claims Claims) error {
+	if claims.ExpiresAt == nil {
+		return errors.New("invalid token")
+	}
+
+	return j.revocationStore.RevokeToken(ctx, claims.Sub, claims.ID, claims.ExpiresAt.Time)
+}
+
+func (j *JwtService) RevokeUserTokens(ctx context.Context, userId string) error {
+	accessTokenExpiry, err := time.ParseDuration(JWT_ACCESS_TOKEN_EXPIRY)
+	if err != nil {
+		return err
+	}
+
+	refreshTokenExpiry, err := time.ParseDuration(JWT_REFRESH_TOKEN_EXPIRY)
+	if err != nil {
+		return err
+	}
+
+	// the revocation is kept until every token issued until now has expired
+	return j.revocationStore.RevokeUserTokens(ctx, userId, time.Now().Add(max(accessTokenExpiry, refreshTokenExpiry)))
+}
+
+// @alchemy block {{- end }}
+
+func NewJwtService(
+	// @alchemy block {{- if .Logout }}
+	revocationStore ITokenRevocationStore,
+	// @alchemy block {{- end }}
+) IJwtService {
+	return &JwtService{
+		// @alchemy block {{- if .Logout }}
+		revocationStore: revocationStore,
+		// @alchemy block {{- end }}
+	}
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type IMailer interface {
+	Send(context.Context, Mail) error
+}
+
+var (
+	SMTP_HOST     string = GetEnv("SMTP_HOST", "localhost")
+	SMTP_PORT     string = GetEnv("SMTP_PORT", "1025")
+	SMTP_USERNAME string = GetEnv("SMTP_USERNAME", "")
+	SMTP_PASSWORD string = GetEnv("SMTP_PASSWORD", "")
+	SMTP_FROM     string = GetEnv("SMTP_FROM", "no-reply@localhost")
+)
+
+// validateMail rejects line breaks in the headers of mail, they would let the
+// recipient or subject add headers of their own
+func validateMail(mail Mail) error {
+	if strings.ContainsAny(mail.To, "\r\n") || strings.ContainsAny(mail.Subject, "\r\n") {
+		return errors.New("mail headers can't contain line breaks")
+	}
+
+	return nil
+}
+
+// SmtpMailer sends mails through the SMTP server configured by the SMTP_*
+// variables, authenticating only if SMTP_USERNAME is set
+type SmtpMailer struct{}
+
+func (s *SmtpMailer) Send(ctx context.Context, mail Mail) error {
+	err := validateMail(mail)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if SMTP_USERNAME != "" {
+		auth = smtp.PlainAuth("", SMTP_USERNAME, SMTP_PASSWORD, SMTP_HOST)
+	}
+
+	message := strings.Join([]string{
+		"From: " + SMTP_FROM,
+		"To: " + mail.To,
+		"Subject: " + mail.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		strings.ReplaceAll(mail.Body, "\n", "\r\n"),
+	}, "\r\n")
+
+	return smtp.SendMail(net.JoinHostPort(SMTP_HOST, SMTP_PORT), auth, SMTP_FROM, []string{mail.To}, []byte(message))
+}
+
+func NewSmtpMailer() IMailer {
+	return &SmtpMailer{}
+}
+
+// ConsoleMailer writes mails to writer instead of sending them, e.g to
+// os.Stdout or a file during development
+type ConsoleMailer struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (c *ConsoleMailer) Send(ctx context.Context, mail Mail) error {
+	err := validateMail(mail)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err = fmt.Fprintf(c.writer, "To: %s\nSubject: %s\n\n%s\n\n", mail.To, mail.Subject, mail.Body)
+
+	return err
+}
+
+func NewConsoleMailer(writer io.Writer) IMailer {
+	return &ConsoleMailer{writer: writer}
+}
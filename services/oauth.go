@@ -0,0 +1,515 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthIdentity is a user as their OAuth provider knows them
+type OAuthIdentity struct {
+	Provider string
+	// Subject identifies the user at the provider, unlike their email it never changes
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     *string
+	LastName      *string
+}
+
+type OAuthConfig struct {
+	ClientId     string
+	ClientSecret string
+	// RedirectUrl is the page the provider returns the user to, with a code and state
+	RedirectUrl string
+	// Scopes replace the default scopes of the provider if set
+	Scopes []string
+}
+
+type OAuthAuthCodeUrlArgs struct {
+	State         string
+	CodeChallenge string
+	Nonce         string
+}
+
+type OAuthExchangeArgs struct {
+	Code         string
+	CodeVerifier string
+	Nonce        string
+}
+
+type IOAuthProvider interface {
+	// Name identifies the provider in linked accounts, e.g google
+	Name() string
+	// AuthCodeUrl is the url users are redirected to, to sign in at the provider
+	AuthCodeUrl(context.Context, OAuthAuthCodeUrlArgs) (string, error)
+	// Exchange trades the code users return with for their identity
+	Exchange(context.Context, OAuthExchangeArgs) (*OAuthIdentity, error)
+}
+
+// NewPkce returns a PKCE code verifier and its S256 code challenge
+func NewPkce() (codeVerifier string, codeChallenge string, err error) {
+	codeVerifier, err = GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.Sum256([]byte(codeVerifier))
+
+	return codeVerifier, base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+func authCodeUrl(endpoint string, config OAuthConfig, scopes []string, args OAuthAuthCodeUrlArgs) (string, error) {
+	authUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	if len(config.Scopes) > 0 {
+		scopes = config.Scopes
+	}
+
+	query := authUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientId)
+	query.Set("redirect_uri", config.RedirectUrl)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", args.State)
+	query.Set("code_challenge", args.CodeChallenge)
+	query.Set("code_challenge_method", "S256")
+	if args.Nonce != "" {
+		query.Set("nonce", args.Nonce)
+	}
+
+	authUrl.RawQuery = query.Encode()
+
+	return authUrl.String(), nil
+}
+
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode requests the tokens of a code from the token endpoint of a provider
+func exchangeCode(ctx context.Context, client *http.Client, endpoint string, config OAuthConfig, args OAuthExchangeArgs) (*oauthTokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {args.Code},
+		"redirect_uri":  {config.RedirectUrl},
+		"client_id":     {config.ClientId},
+		"client_secret": {config.ClientSecret},
+		"code_verifier": {args.CodeVerifier},
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	tokens := oauthTokenResponse{}
+	err = json.NewDecoder(response.Body).Decode(&tokens)
+
+	// github reports errors with a 200 status
+	if response.StatusCode != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf(
+			"oauth code exchange failed with status %d: %s",
+			response.StatusCode,
+			strings.TrimSpace(tokens.Error+" "+tokens.ErrorDescription),
+		)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid oauth token response: %w", err)
+	}
+
+	return &tokens, nil
+}
+
+// getJson decodes the response of a GET request into v
+func getJson(ctx context.Context, client *http.Client, endpoint string, accessToken string, v any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed with status %d", endpoint, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// OidcProvider signs users in with any OpenID Connect provider, its endpoints
+// are discovered from the issuer and the id tokens it issues are verified with
+// the keys of the issuer
+type OidcProvider struct {
+	name   string
+	issuer string
+	config OAuthConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func (o *OidcProvider) Name() string {
+	return o.name
+}
+
+func (o *OidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	discovery := oidcDiscovery{}
+	err := getJson(ctx, o.client, strings.TrimSuffix(o.issuer, "/")+"/.well-known/openid-configuration", "", &discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if discovery.Issuer != o.issuer {
+		return nil, fmt.Errorf("oidc issuer `%s` doesn't match `%s`", discovery.Issuer, o.issuer)
+	}
+
+	o.discovery = &discovery
+
+	return o.discovery, nil
+}
+
+// key returns the public key with id kid, the keys are fetched again when the
+// issuer rotated them
+func (o *OidcProvider) key(ctx context.Context, jwksUri string, kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+
+	jwks := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	err := getJson(ctx, o.client, jwksUri, "", &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	o.keys = map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		o.keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	key, ok := o.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc key `%s` not found", kid)
+	}
+
+	return key, nil
+}
+
+func (o *OidcProvider) AuthCodeUrl(ctx context.Context, args OAuthAuthCodeUrlArgs) (string, error) {
+	discovery, err := o.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return authCodeUrl(discovery.AuthorizationEndpoint, o.config, []string{"openid", "email", "profile"}, args)
+}
+
+func (o *OidcProvider) Exchange(ctx context.Context, args OAuthExchangeArgs) (*OAuthIdentity, error) {
+	discovery, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := exchangeCode(ctx, o.client, discovery.TokenEndpoint, o.config, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens.IdToken == "" {
+		return nil, errors.New("oidc provider returned no id token")
+	}
+
+	claims := oidcClaims{}
+	_, err = jwt.ParseWithClaims(
+		tokens.IdToken,
+		&claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return o.key(ctx, discovery.JwksUri, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(o.issuer),
+		jwt.WithAudience(o.config.ClientId),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	// the nonce ties the id token to the sign in that was started, so a stolen
+	// id token can't be replayed
+	if claims.Nonce != args.Nonce {
+		return nil, errors.New("invalid id token: nonce doesn't match")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: subject is missing")
+	}
+
+	identity := &OAuthIdentity{
+		Provider:      o.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+
+	if claims.GivenName != "" {
+		identity.FirstName = &claims.GivenName
+	}
+
+	if claims.FamilyName != "" {
+		identity.LastName = &claims.FamilyName
+	}
+
+	return identity, nil
+}
+
+// NewOidcProvider signs users in with the OpenID Connect provider at issuer,
+// e.g https://accounts.google.com. Name identifies it in linked accounts.
+func NewOidcProvider(name string, issuer string, config OAuthConfig) IOAuthProvider {
+	return &OidcProvider{
+		name:   name,
+		issuer: issuer,
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func NewGoogleProvider(config OAuthConfig) IOAuthProvider {
+	return NewOidcProvider("google", "https://accounts.google.com", config)
+}
+
+// GithubProvider signs users in with GitHub, which only supports OAuth, so
+// users are fetched from its api with the access token
+type GithubProvider struct {
+	config   OAuthConfig
+	client   *http.Client
+	authUrl  string
+	tokenUrl string
+	apiUrl   string
+}
+
+func (g *GithubProvider) Name() string {
+	return "github"
+}
+
+func (g *GithubProvider) AuthCodeUrl(ctx context.Context, args OAuthAuthCodeUrlArgs) (string, error) {
+	return authCodeUrl(g.authUrl, g.config, []string{"read:user", "user:email"}, OAuthAuthCodeUrlArgs{
+		State:         args.State,
+		CodeChallenge: args.CodeChallenge,
+	})
+}
+
+func (g *GithubProvider) Exchange(ctx context.Context, args OAuthExchangeArgs) (*OAuthIdentity, error) {
+	tokens, err := exchangeCode(ctx, g.client, g.tokenUrl, g.config, args)
+	if err != nil {
+		return nil, err
+	}
+
+	user := struct {
+		Id   int64  `json:"id"`
+		Name string `json:"name"`
+	}{}
+	err = getJson(ctx, g.client, g.apiUrl+"/user", tokens.AccessToken, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	// the email of a github user is only public if they chose so, and may not
+	// be verified, so the primary email is used instead
+	emails := []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}{}
+	err = getJson(ctx, g.client, g.apiUrl+"/user/emails", tokens.AccessToken, &emails)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &OAuthIdentity{Provider: g.Name(), Subject: strconv.FormatInt(user.Id, 10)}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	if firstName, lastName, _ := strings.Cut(user.Name, " "); firstName != "" {
+		identity.FirstName = &firstName
+		if lastName != "" {
+			identity.LastName = &lastName
+		}
+	}
+
+	return identity, nil
+}
+
+func newGithubProvider(config OAuthConfig, authUrl string, tokenUrl string, apiUrl string) IOAuthProvider {
+	return &GithubProvider{
+		config:   config,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		authUrl:  authUrl,
+		tokenUrl: tokenUrl,
+		apiUrl:   apiUrl,
+	}
+}
+
+func NewGithubProvider(config OAuthConfig) IOAuthProvider {
+	return newGithubProvider(
+		config,
+		"https://github.com/login/oauth/authorize",
+		"https://github.com/login/oauth/access_token",
+		"https://api.github.com",
+	)
+}
+
+// OAuthState is kept from redirecting users to their provider until they
+// return, the state parameter of the redirects is its key
+type OAuthState struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+var ErrInvalidOAuthState = errors.New("invalid oauth state")
+
+type IOAuthStateStore interface {
+	Save(ctx context.Context, state string, oauthState OAuthState) error
+	// Take returns and deletes the OAuthState of state, so it can only be used
+	// once. It returns ErrInvalidOAuthState if it doesn't exist or expired.
+	Take(ctx context.Context, state string) (*OAuthState, error)
+}
+
+// MemoryOAuthStateStore keeps states in memory, so users have to return to
+// the same instance of the application they started signing in with
+type MemoryOAuthStateStore struct {
+	mu     sync.Mutex
+	states map[string]OAuthState
+}
+
+func (m *MemoryOAuthStateStore) Save(ctx context.Context, state string, oauthState OAuthState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// users who never return leave their states behind
+	for key, value := range m.states {
+		if time.Now().After(value.ExpiresAt) {
+			delete(m.states, key)
+		}
+	}
+
+	m.states[state] = oauthState
+
+	return nil
+}
+
+func (m *MemoryOAuthStateStore) Take(ctx context.Context, state string) (*OAuthState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oauthState, ok := m.states[state]
+	if !ok {
+		return nil, ErrInvalidOAuthState
+	}
+
+	delete(m.states, state)
+
+	if time.Now().After(oauthState.ExpiresAt) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	return &oauthState, nil
+}
+
+func NewMemoryOAuthStateStore() IOAuthStateStore {
+	return &MemoryOAuthStateStore{states: map[string]OAuthState{}}
+}
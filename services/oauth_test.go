@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockOidcServer is an OpenID Connect provider which issues a code for every
+// authorization url it's given, as if the user signed in
+type mockOidcServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	audience string
+	codes    map[string]url.Values
+}
+
+func newMockOidcServer(t *testing.T) *mockOidcServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOidcServer{key: key, audience: "client-id", codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk := map[string]string{
+			"kid": "key-1",
+			"kty": "RSA",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		query, ok := m.codes[r.Form.Get("code")]
+		delete(m.codes, r.Form.Get("code"))
+
+		hash := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || query.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(hash[:]) || r.Form.Get("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken, err := m.idToken(query.Get("nonce"))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockOidcServer) idToken(nonce string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            m.audience,
+		"sub":            "user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "key-1"
+
+	return token.SignedString(m.key)
+}
+
+// authorize returns the code the user returns with after signing in at the
+// authorization url
+func (m *mockOidcServer) authorize(t *testing.T, authCodeUrl string) string {
+	parsedUrl, err := url.Parse(authCodeUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateRandomToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.codes[code] = parsedUrl.Query()
+
+	return code
+}
+
+func newTestOidcProvider(m *mockOidcServer) IOAuthProvider {
+	return NewOidcProvider("mock", m.URL, OAuthConfig{
+		ClientId:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectUrl:  "http://localhost:3000/oauth/callback",
+	})
+}
+
+// signIn goes through the authorization url of provider, and returns the args
+// the user returns with
+func signIn(t *testing.T, m *mockOidcServer, provider IOAuthProvider) OAuthExchangeArgs {
+	codeVerifier, codeChallenge, err := NewPkce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authCodeUrl, err := provider.AuthCodeUrl(context.Background(), OAuthAuthCodeUrlArgs{
+		State:         "state",
+		CodeChallenge: codeChallenge,
+		Nonce:         "nonce",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return OAuthExchangeArgs{Code: m.authorize(t, authCodeUrl), CodeVerifier: codeVerifier, Nonce: "nonce"}
+}
+
+func TestOidcProviderAuthCodeUrl(t *testing.T) {
+	m := newMockOidcServer(t)
+
+	authCodeUrl, err := newTestOidcProvider(m).AuthCodeUrl(context.Background(), OAuthAuthCodeUrlArgs{
+		State:         "state",
+		CodeChallenge: "challenge",
+		Nonce:         "nonce",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedUrl, err := url.Parse(authCodeUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedUrl.Path != "/authorize" {
+		t.Errorf("expected path /authorize, got %s", parsedUrl.Path)
+	}
+
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-id",
+		"redirect_uri":          "http://localhost:3000/oauth/callback",
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+		"nonce":                 "nonce",
+	}
+	for key, value := range expected {
+		if parsedUrl.Query().Get(key) != value {
+			t.Errorf("expected %s to be %s, got %s", key, value, parsedUrl.Query().Get(key))
+		}
+	}
+}
+
+func TestOidcProviderExchange(t *testing.T) {
+	m := newMockOidcServer(t)
+	provider := newTestOidcProvider(m)
+
+	identity, err := provider.Exchange(context.Background(), signIn(t, m, provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.Provider != "mock" || identity.Subject != "user-1" {
+		t.Errorf("unexpected identity %s/%s", identity.Provider, identity.Subject)
+	}
+
+	if identity.Email != "jane@example.com" || !identity.EmailVerified {
+		t.Errorf("expected verified email jane@example.com, got %s", identity.Email)
+	}
+
+	if identity.FirstName == nil || *identity.FirstName != "Jane" || identity.LastName == nil || *identity.LastName != "Doe" {
+		t.Error("expected name Jane Doe")
+	}
+}
+
+func TestOidcProviderRejectsWrongCodeVerifier(t *testing.T) {
+	m := newMockOidcServer(t)
+	provider := newTestOidcProvider(m)
+
+	args := signIn(t, m, provider)
+	args.CodeVerifier = "wrong"
+
+	_, err := provider.Exchange(context.Background(), args)
+	if err == nil {
+		t.Fatal("expected the exchange to fail")
+	}
+}
+
+func TestOidcProviderRejectsWrongNonce(t *testing.T) {
+	m := newMockOidcServer(t)
+	provider := newTestOidcProvider(m)
+
+	args := signIn(t, m, provider)
+	args.Nonce = "other-nonce"
+
+	_, err := provider.Exchange(context.Background(), args)
+	if err == nil {
+		t.Fatal("expected the id token to be rejected")
+	}
+}
+
+func TestOidcProviderRejectsWrongAudience(t *testing.T) {
+	m := newMockOidcServer(t)
+	m.audience = "other-client-id"
+	provider := newTestOidcProvider(m)
+
+	_, err := provider.Exchange(context.Background(), signIn(t, m, provider))
+	if err == nil {
+		t.Fatal("expected the id token to be rejected")
+	}
+}
+
+func TestOidcProviderRejectsUnknownSigner(t *testing.T) {
+	m := newMockOidcServer(t)
+	provider := newTestOidcProvider(m)
+
+	// the token endpoint signs with a key the issuer doesn't publish
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.key = key
+
+	_, err = provider.Exchange(context.Background(), signIn(t, m, provider))
+	if err == nil {
+		t.Fatal("expected the id token to be rejected")
+	}
+}
+
+func TestMemoryOAuthStateStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryOAuthStateStore()
+
+	err := store.Save(ctx, "state", OAuthState{Provider: "mock", ExpiresAt: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oauthState, err := store.Take(ctx, "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if oauthState.Provider != "mock" {
+		t.Errorf("expected provider mock, got %s", oauthState.Provider)
+	}
+
+	_, err = store.Take(ctx, "state")
+	if err != ErrInvalidOAuthState {
+		t.Errorf("expected a state to be used once, got %v", err)
+	}
+
+	err = store.Save(ctx, "expired", OAuthState{Provider: "mock", ExpiresAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Take(ctx, "expired")
+	if err != ErrInvalidOAuthState {
+		t.Errorf("expected an expired state to be rejected, got %v", err)
+	}
+}
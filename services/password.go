@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type IPasswordHasher interface {
+	Hash(context.Context, string) (string, error)
+	Verify(context.Context, string, string) (bool, error)
+	// NeedsRehash reports whether a hash wasn't created with the configured
+	// algorithm and parameters, so it should be replaced once the password is known
+	NeedsRehash(context.Context, string) (bool, error)
+}
+
+type PasswordHasher struct{}
+
+var (
+	PASSWORD_HASHER_ALGORITHM string = GetEnv("PASSWORD_HASHER_ALGORITHM", "argon2id") // argon2id or bcrypt
+	ARGON2ID_MEMORY           string = GetEnv("ARGON2ID_MEMORY", "65536")              // KiB
+	ARGON2ID_ITERATIONS       string = GetEnv("ARGON2ID_ITERATIONS", "3")
+	ARGON2ID_PARALLELISM      string = GetEnv("ARGON2ID_PARALLELISM", "2")
+	BCRYPT_COST               string = GetEnv("BCRYPT_COST", "12")
+)
+
+const (
+	argon2idSaltLength uint32 = 16
+	argon2idKeyLength  uint32 = 32
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+type argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func (p *PasswordHasher) configuredArgon2idParams() (*argon2idParams, error) {
+	memory, err := strconv.ParseUint(ARGON2ID_MEMORY, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARGON2ID_MEMORY: %w", err)
+	}
+
+	iterations, err := strconv.ParseUint(ARGON2ID_ITERATIONS, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARGON2ID_ITERATIONS: %w", err)
+	}
+
+	parallelism, err := strconv.ParseUint(ARGON2ID_PARALLELISM, 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARGON2ID_PARALLELISM: %w", err)
+	}
+
+	return &argon2idParams{
+		Memory:      uint32(memory),
+		Iterations:  uint32(iterations),
+		Parallelism: uint8(parallelism),
+	}, nil
+}
+
+func (p *PasswordHasher) bcryptCost() (int, error) {
+	cost, err := strconv.Atoi(BCRYPT_COST)
+	if err != nil {
+		return 0, fmt.Errorf("invalid BCRYPT_COST: %w", err)
+	}
+
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return 0, fmt.Errorf("invalid BCRYPT_COST: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return cost, nil
+}
+
+// hashArgon2id encodes the hash in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func (p *PasswordHasher) hashArgon2id(plain string, params argon2idParams) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, params.Iterations, params.Memory, params.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (p *PasswordHasher) decodeArgon2id(hashed string) (params *argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params = &argon2idParams{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	return params, salt, key, nil
+}
+
+func (p *PasswordHasher) isArgon2id(hashed string) bool {
+	return strings.HasPrefix(hashed, "$argon2id$")
+}
+
+func (p *PasswordHasher) isBcrypt(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+// Hash hashes plain with the configured algorithm
+func (p *PasswordHasher) Hash(ctx context.Context, plain string) (string, error) {
+	switch PASSWORD_HASHER_ALGORITHM {
+	case "argon2id":
+		params, err := p.configuredArgon2idParams()
+		if err != nil {
+			return "", err
+		}
+
+		return p.hashArgon2id(plain, *params)
+	case "bcrypt":
+		cost, err := p.bcryptCost()
+		if err != nil {
+			return "", err
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(plain), cost)
+		if err != nil {
+			return "", err
+		}
+
+		return string(hashed), nil
+	default:
+		return "", fmt.Errorf("unsupported PASSWORD_HASHER_ALGORITHM `%s`", PASSWORD_HASHER_ALGORITHM)
+	}
+}
+
+// Verify compares plain with a hash of either algorithm in constant time, so
+// hashes keep working after PASSWORD_HASHER_ALGORITHM is changed
+func (p *PasswordHasher) Verify(ctx context.Context, plain string, hashed string) (bool, error) {
+	switch {
+	case p.isArgon2id(hashed):
+		params, salt, key, err := p.decodeArgon2id(hashed)
+		if err != nil {
+			return false, err
+		}
+
+		otherKey := argon2.IDKey([]byte(plain), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+	case p.isBcrypt(hashed):
+		err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	default:
+		return false, ErrInvalidPasswordHash
+	}
+}
+
+func (p *PasswordHasher) NeedsRehash(ctx context.Context, hashed string) (bool, error) {
+	switch PASSWORD_HASHER_ALGORITHM {
+	case "argon2id":
+		if !p.isArgon2id(hashed) {
+			return true, nil
+		}
+
+		params, err := p.configuredArgon2idParams()
+		if err != nil {
+			return false, err
+		}
+
+		hashParams, salt, key, err := p.decodeArgon2id(hashed)
+		if err != nil {
+			return false, err
+		}
+
+		return *hashParams != *params || uint32(len(salt)) != argon2idSaltLength || uint32(len(key)) != argon2idKeyLength, nil
+	case "bcrypt":
+		if !p.isBcrypt(hashed) {
+			return true, nil
+		}
+
+		cost, err := p.bcryptCost()
+		if err != nil {
+			return false, err
+		}
+
+		hashCost, err := bcrypt.Cost([]byte(hashed))
+		if err != nil {
+			return false, err
+		}
+
+		return hashCost != cost, nil
+	default:
+		return false, fmt.Errorf("unsupported PASSWORD_HASHER_ALGORITHM `%s`", PASSWORD_HASHER_ALGORITHM)
+	}
+}
+
+func NewPasswordHasher() IPasswordHasher {
+	return &PasswordHasher{}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+// IRateLimiter limits how often something is attempted per key, e.g per user
+// or email
+type IRateLimiter interface {
+	// Allow records an attempt of key, and reports whether it is within the limit
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+type rateLimitWindow struct {
+	attempts int
+	resetAt  time.Time
+}
+
+// MemoryRateLimiter counts attempts in memory, so each instance of the app
+// has its own limits
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]rateLimitWindow
+}
+
+func (m *MemoryRateLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	for windowKey, window := range m.windows {
+		if now.After(window.resetAt) {
+			delete(m.windows, windowKey)
+		}
+	}
+
+	window, ok := m.windows[key]
+	if !ok {
+		window = rateLimitWindow{resetAt: now.Add(m.window)}
+	}
+
+	window.attempts++
+	m.windows[key] = window
+
+	return window.attempts <= m.limit, nil
+}
+
+// NewMemoryRateLimiter allows limit attempts per key within each window,
+// e.g 5 per 5 minutes
+func NewMemoryRateLimiter(limit int, window time.Duration) IRateLimiter {
+	return &MemoryRateLimiter{limit: limit, window: window, windows: map[string]rateLimitWindow{}}
+}
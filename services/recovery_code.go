@@ -0,0 +1,29 @@
+package services
+
+import (
+	"strings"
+)
+
+// GenerateRecoveryCode returns a random recovery code, grouped like
+// xxxxx-xxxxx-xxxxx-xxxxx so it can be written down
+func GenerateRecoveryCode() (string, error) {
+	token, err := GenerateRandomToken(10)
+	if err != nil {
+		return "", err
+	}
+
+	groups := []string{}
+	for i := 0; i < len(token); i += 5 {
+		groups = append(groups, token[i:i+5])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// HashRecoveryCode hashes a recovery code before it is stored or looked up,
+// ignoring the case and the separators the user types it with
+func HashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+
+	return HashToken(normalized)
+}
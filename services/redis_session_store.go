@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSessionStore keeps the sessions in redis, they're shared by every
+// instance of the app and expire on their own
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+func (RedisSessionStore) sessionKey(tokenHash string) string {
+	return "session:" + tokenHash
+}
+
+// userKey is the set of token hashes of a user's sessions, so they can all be
+// deleted at once
+func (RedisSessionStore) userKey(userId string) string {
+	return "user-sessions:" + userId
+}
+
+func (r *RedisSessionStore) Create(ctx context.Context, session Session) (*Session, error) {
+	id, err := GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Id = id
+
+	value, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.sessionKey(session.TokenHash), value, time.Until(session.ExpiresAt))
+		pipe.SAdd(ctx, r.userKey(session.UserId), session.TokenHash)
+		// sessions all last as long, so the newest session expires last
+		pipe.ExpireAt(ctx, r.userKey(session.UserId), session.ExpiresAt)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *RedisSessionStore) Get(ctx context.Context, tokenHash string) (*Session, error) {
+	value, err := r.client.Get(ctx, r.sessionKey(tokenHash)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidSession
+		}
+
+		return nil, err
+	}
+
+	session := Session{}
+	err = json.Unmarshal(value, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token hash isn't marshalled
+	session.TokenHash = tokenHash
+
+	return &session, nil
+}
+
+func (r *RedisSessionStore) Touch(ctx context.Context, session Session, lastSeenAt time.Time) error {
+	session.LastSeenAt = lastSeenAt
+
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// SetXX doesn't bring back a session which was deleted meanwhile
+	return r.client.SetXX(ctx, r.sessionKey(session.TokenHash), value, redis.KeepTTL).Err()
+}
+
+func (r *RedisSessionStore) Delete(ctx context.Context, session Session) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, r.sessionKey(session.TokenHash))
+		pipe.SRem(ctx, r.userKey(session.UserId), session.TokenHash)
+
+		return nil
+	})
+
+	return err
+}
+
+func (r *RedisSessionStore) DeleteAllOfUser(ctx context.Context, userId string) error {
+	tokenHashes, err := r.client.SMembers(ctx, r.userKey(userId)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{r.userKey(userId)}
+	for _, tokenHash := range tokenHashes {
+		keys = append(keys, r.sessionKey(tokenHash))
+	}
+
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// NewRedisSessionStore keeps the sessions in the redis of client, its options
+// can be parsed from REDIS_URL with redis.ParseURL
+func NewRedisSessionStore(client *redis.Client) ISessionStore {
+	return &RedisSessionStore{client: client}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/dao"
+	"github.com/struckchure/go-alchemy/orms/prisma"
+	// @alchemy replace
+	"github.com/struckchure/go-alchemy/orms/shared"
+)
+
+var (
+	SESSION_COOKIE_NAME   string = GetEnv("SESSION_COOKIE_NAME", "session")
+	SESSION_COOKIE_SECURE string = GetEnv("SESSION_COOKIE_SECURE", "true") // false only for http during development
+	// SESSION_IDLE_TIMEOUT ends the sessions which weren't used for that long
+	SESSION_IDLE_TIMEOUT string = GetEnv("SESSION_IDLE_TIMEOUT", "30m")
+	// SESSION_ABSOLUTE_TIMEOUT ends every session that long after the login, even
+	// the ones still in use
+	SESSION_ABSOLUTE_TIMEOUT string = GetEnv("SESSION_ABSOLUTE_TIMEOUT", "24h")
+	SESSION_CSRF_SECRET      string = GetEnv("SESSION_CSRF_SECRET", "csrf-secret")
+)
+
+var ErrInvalidSession = errors.New("invalid session")
+
+// Session keeps a user logged in, the token of its cookie is only known to the
+// browser, the store keeps its hash
+type Session struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// ISessionStore keeps the sessions until they end or expire
+type ISessionStore interface {
+	Create(ctx context.Context, session Session) (*Session, error)
+	// Get returns ErrInvalidSession if no session has the token hash
+	Get(ctx context.Context, tokenHash string) (*Session, error)
+	// Touch records that the session was used at lastSeenAt
+	Touch(ctx context.Context, session Session, lastSeenAt time.Time) error
+	Delete(ctx context.Context, session Session) error
+	DeleteAllOfUser(ctx context.Context, userId string) error
+}
+
+// DaoSessionStore keeps the sessions in the database, so they're shared by
+// every instance of the app
+type DaoSessionStore struct {
+	// @alchemy replace sessionDao dao.ISessionDao
+	sessionDao prisma.ISessionDao
+}
+
+// @alchemy replace func (DaoSessionStore) fromModel(session *dao.Session) *Session {
+func (DaoSessionStore) fromModel(session *prisma.Session) *Session {
+	return &Session{
+		Id:         session.Id,
+		UserId:     session.UserId,
+		TokenHash:  session.TokenHash,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+	}
+}
+
+func (d *DaoSessionStore) Create(ctx context.Context, session Session) (*Session, error) {
+	err := d.sessionDao.DeleteExpired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := d.sessionDao.Create(
+		ctx,
+		// @alchemy replace dao.SessionCreatePayload{
+		prisma.SessionCreatePayload{
+			UserId:     session.UserId,
+			TokenHash:  session.TokenHash,
+			ExpiresAt:  session.ExpiresAt,
+			LastSeenAt: session.LastSeenAt,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.fromModel(model), nil
+}
+
+func (d *DaoSessionStore) Get(ctx context.Context, tokenHash string) (*Session, error) {
+	model, err := d.sessionDao.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		// @alchemy replace if errors.Is(err, dao.ErrNotFound) {
+		if errors.Is(err, shared.ErrNotFound) {
+			return nil, ErrInvalidSession
+		}
+
+		return nil, err
+	}
+
+	return d.fromModel(model), nil
+}
+
+func (d *DaoSessionStore) Touch(ctx context.Context, session Session, lastSeenAt time.Time) error {
+	return d.sessionDao.SetLastSeenAt(ctx, session.Id, lastSeenAt)
+}
+
+func (d *DaoSessionStore) Delete(ctx context.Context, session Session) error {
+	return d.sessionDao.Delete(ctx, session.Id)
+}
+
+func (d *DaoSessionStore) DeleteAllOfUser(ctx context.Context, userId string) error {
+	return d.sessionDao.DeleteAllOfUser(ctx, userId)
+}
+
+func NewDaoSessionStore(
+	// @alchemy replace sessionDao dao.ISessionDao,
+	sessionDao prisma.ISessionDao,
+) ISessionStore {
+	return &DaoSessionStore{sessionDao: sessionDao}
+}
+
+type SessionResult struct {
+	// Token belongs in the session cookie only, see Cookie
+	Token string `json:"-"`
+	// CsrfToken is sent back by the pages of the app with each unsafe request,
+	// e.g in a hidden form field or a header
+	CsrfToken string    `json:"csrfToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Cookie is the session cookie to set on the response of the login
+func (s SessionResult) Cookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     SESSION_COOKIE_NAME,
+		Value:    s.Token,
+		Path:     "/",
+		Expires:  s.ExpiresAt,
+		HttpOnly: true,
+		Secure:   SESSION_COOKIE_SECURE == "true",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ClearSessionCookie is the cookie to set on the response of a logout, it
+// deletes the session cookie of the browser
+func ClearSessionCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     SESSION_COOKIE_NAME,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   SESSION_COOKIE_SECURE == "true",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SessionToken returns the token of the session cookie of a request, or an
+// empty string without one
+func SessionToken(r *http.Request) string {
+	cookie, err := r.Cookie(SESSION_COOKIE_NAME)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// CsrfToken returns the csrf token of a session, it's derived from the session
+// token so it needs no storage and changes along with the session
+func CsrfToken(sessionToken string) string {
+	mac := hmac.New(sha256.New, []byte(SESSION_CSRF_SECRET))
+	mac.Write([]byte(sessionToken))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateCsrfToken reports whether csrfToken belongs to the session of
+// sessionToken
+func ValidateCsrfToken(sessionToken string, csrfToken string) bool {
+	if sessionToken == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(CsrfToken(sessionToken)), []byte(csrfToken))
+}
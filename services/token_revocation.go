@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	// @alchemy statement "{{ .ModuleName }}/dao"
+	"github.com/struckchure/go-alchemy/orms/prisma"
+)
+
+// ITokenRevocationStore keeps the revoked tokens until they expire, JwtService
+// rejects the tokens it reports as revoked
+type ITokenRevocationStore interface {
+	// RevokeToken revokes the token with id jti until expiresAt
+	RevokeToken(ctx context.Context, userId string, jti string, expiresAt time.Time) error
+	// RevokeUserTokens revokes the tokens of a user issued until now, the
+	// revocation is kept until expiresAt
+	RevokeUserTokens(ctx context.Context, userId string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims Claims) (bool, error)
+}
+
+// issuedAt returns when the token of claims was issued
+func issuedAt(claims Claims) time.Time {
+	if claims.IssuedAt == nil {
+		return time.Time{}
+	}
+
+	return claims.IssuedAt.Time
+}
+
+type userRevocation struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryTokenRevocationStore keeps the revoked tokens in memory, they are lost
+// when the process exits and aren't shared with other instances of the app
+type MemoryTokenRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	users  map[string]userRevocation
+}
+
+// deleteExpired deletes the revocations of tokens which expired anyway, mu
+// must be held
+func (m *MemoryTokenRevocationStore) deleteExpired() {
+	now := time.Now()
+
+	for jti, expiresAt := range m.tokens {
+		if expiresAt.Before(now) {
+			delete(m.tokens, jti)
+		}
+	}
+
+	for userId, revocation := range m.users {
+		if revocation.expiresAt.Before(now) {
+			delete(m.users, userId)
+		}
+	}
+}
+
+func (m *MemoryTokenRevocationStore) RevokeToken(ctx context.Context, userId string, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deleteExpired()
+	m.tokens[jti] = expiresAt
+
+	return nil
+}
+
+func (m *MemoryTokenRevocationStore) RevokeUserTokens(ctx context.Context, userId string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deleteExpired()
+	m.users[userId] = userRevocation{revokedAt: time.Now(), expiresAt: expiresAt}
+
+	return nil
+}
+
+func (m *MemoryTokenRevocationStore) IsRevoked(ctx context.Context, claims Claims) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tokens[claims.ID]; ok {
+		return true, nil
+	}
+
+	revocation, ok := m.users[claims.Sub]
+
+	return ok && revocation.revokedAt.After(issuedAt(claims)), nil
+}
+
+func NewMemoryTokenRevocationStore() ITokenRevocationStore {
+	return &MemoryTokenRevocationStore{
+		tokens: map[string]time.Time{},
+		users:  map[string]userRevocation{},
+	}
+}
+
+// DaoTokenRevocationStore keeps the revoked tokens in the database, so they're
+// shared by every instance of the app
+type DaoTokenRevocationStore struct {
+	// @alchemy replace revokedTokenDao dao.IRevokedTokenDao
+	revokedTokenDao prisma.IRevokedTokenDao
+}
+
+func (d *DaoTokenRevocationStore) RevokeToken(ctx context.Context, userId string, jti string, expiresAt time.Time) error {
+	err := d.revokedTokenDao.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.revokedTokenDao.Create(
+		ctx,
+		// @alchemy replace dao.RevokedTokenCreatePayload{
+		prisma.RevokedTokenCreatePayload{
+			Jti:       &jti,
+			UserId:    userId,
+			ExpiresAt: expiresAt,
+		},
+	)
+
+	return err
+}
+
+func (d *DaoTokenRevocationStore) RevokeUserTokens(ctx context.Context, userId string, expiresAt time.Time) error {
+	err := d.revokedTokenDao.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.revokedTokenDao.Create(
+		ctx,
+		// @alchemy replace dao.RevokedTokenCreatePayload{
+		prisma.RevokedTokenCreatePayload{
+			UserId:    userId,
+			ExpiresAt: expiresAt,
+		},
+	)
+
+	return err
+}
+
+func (d *DaoTokenRevocationStore) IsRevoked(ctx context.Context, claims Claims) (bool, error) {
+	return d.revokedTokenDao.IsRevoked(ctx, claims.ID, claims.Sub, issuedAt(claims))
+}
+
+func NewDaoTokenRevocationStore(
+	// @alchemy replace revokedTokenDao dao.IRevokedTokenDao,
+	revokedTokenDao prisma.IRevokedTokenDao,
+) ITokenRevocationStore {
+	return &DaoTokenRevocationStore{revokedTokenDao: revokedTokenDao}
+}
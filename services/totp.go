@@ -0,0 +1,101 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the codes of authenticator apps, e.g Google Authenticator, are TOTP codes
+// (RFC 6238) with these parameters
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew accepts the codes of the periods before and after the current
+	// one too, as the clocks of phones drift
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTotpSecret returns a random secret, base32 encoded as authenticator
+// apps expect it
+func GenerateTotpSecret() (string, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TotpUri returns the otpauth uri of a secret, authenticator apps add the
+// secret by scanning the uri as a QR code
+func TotpUri(issuer string, account string, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+
+	// some authenticator apps don't decode + as a space
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func totpCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// TotpCode returns the code of secret at t
+func TotpCode(secret string, t time.Time) (string, error) {
+	return totpCode(secret, t.Unix()/totpPeriod)
+}
+
+// ValidateTotpCode reports whether code is the code of secret at t, or of the
+// periods next to it, and returns its time step. The codes of the steps up to
+// lastStep were accepted already, so they aren't accepted again
+func ValidateTotpCode(secret string, code string, t time.Time, lastStep int64) (int64, bool, error) {
+	counter := t.Unix() / totpPeriod
+
+	for skew := int64(-totpSkew); skew <= totpSkew; skew++ {
+		if counter+skew <= lastStep {
+			continue
+		}
+
+		expected, err := totpCode(secret, counter+skew)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + skew, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
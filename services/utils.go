@@ -1,6 +1,9 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 
 	"github.com/samber/lo"
@@ -22,3 +25,21 @@ func GetIfPresent(value *string) *string {
 	}
 	return value
 }
+
+// GenerateRandomToken returns size random bytes, hex encoded
+func GenerateRandomToken(size int) (string, error) {
+	token := make([]byte, size)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// HashToken hashes a token before it is stored. Unlike passwords, tokens are
+// random and long enough that a fast hash can't be brute forced.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}